@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"nesutaro/config"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// binding is a key, a gamepad button or a gamepad stick direction.
+type binding interface {
+	isPressed(pad gamepad) bool
+}
+
+// gamepad is the gamepad a binding is read from.
+type gamepad struct {
+	id       ebiten.GamepadID
+	deadzone float64
+}
+
+type keyBinding ebiten.Key
+
+func (b keyBinding) isPressed(gamepad) bool {
+	return ebiten.IsKeyPressed(ebiten.Key(b))
+}
+
+// standardButtonBinding works on gamepads with a standard layout mapping.
+type standardButtonBinding ebiten.StandardGamepadButton
+
+func (b standardButtonBinding) isPressed(pad gamepad) bool {
+	return ebiten.IsStandardGamepadButtonPressed(pad.id, ebiten.StandardGamepadButton(b))
+}
+
+// buttonBinding is a raw button index, for gamepads without a standard layout.
+type buttonBinding ebiten.GamepadButton
+
+func (b buttonBinding) isPressed(pad gamepad) bool {
+	return ebiten.IsGamepadButtonPressed(pad.id, ebiten.GamepadButton(b))
+}
+
+// stickBinding is pressed while the stick is tilted past the deadzone in one direction.
+type stickBinding struct {
+	axis ebiten.StandardGamepadAxis
+	sign float64 // -1 = up / left, 1 = down / right
+}
+
+func (b stickBinding) isPressed(pad gamepad) bool {
+	return ebiten.StandardGamepadAxisValue(pad.id, b.axis)*b.sign > pad.deadzone
+}
+
+// Gamepad button names, positioned as on an Xbox controller
+var standardButtonNames = map[string]ebiten.StandardGamepadButton{
+	"a":      ebiten.StandardGamepadButtonRightBottom,
+	"b":      ebiten.StandardGamepadButtonRightRight,
+	"x":      ebiten.StandardGamepadButtonRightLeft,
+	"y":      ebiten.StandardGamepadButtonRightTop,
+	"lb":     ebiten.StandardGamepadButtonFrontTopLeft,
+	"rb":     ebiten.StandardGamepadButtonFrontTopRight,
+	"lt":     ebiten.StandardGamepadButtonFrontBottomLeft,
+	"rt":     ebiten.StandardGamepadButtonFrontBottomRight,
+	"back":   ebiten.StandardGamepadButtonCenterLeft,
+	"start":  ebiten.StandardGamepadButtonCenterRight,
+	"home":   ebiten.StandardGamepadButtonCenterCenter,
+	"ls":     ebiten.StandardGamepadButtonLeftStick,
+	"rs":     ebiten.StandardGamepadButtonRightStick,
+	"up":     ebiten.StandardGamepadButtonLeftTop,
+	"down":   ebiten.StandardGamepadButtonLeftBottom,
+	"left":   ebiten.StandardGamepadButtonLeftLeft,
+	"right":  ebiten.StandardGamepadButtonLeftRight,
+	"select": ebiten.StandardGamepadButtonCenterLeft,
+}
+
+var stickNames = map[string]stickBinding{
+	"leftstickup":     {ebiten.StandardGamepadAxisLeftStickVertical, -1},
+	"leftstickdown":   {ebiten.StandardGamepadAxisLeftStickVertical, 1},
+	"leftstickleft":   {ebiten.StandardGamepadAxisLeftStickHorizontal, -1},
+	"leftstickright":  {ebiten.StandardGamepadAxisLeftStickHorizontal, 1},
+	"rightstickup":    {ebiten.StandardGamepadAxisRightStickVertical, -1},
+	"rightstickdown":  {ebiten.StandardGamepadAxisRightStickVertical, 1},
+	"rightstickleft":  {ebiten.StandardGamepadAxisRightStickHorizontal, -1},
+	"rightstickright": {ebiten.StandardGamepadAxisRightStickHorizontal, 1},
+}
+
+// parseBinding reads a binding name: an ebiten.Key name (e.g. "Z", "ShiftLeft"),
+// "Gamepad:<button>" (A, B, X, Y, LB, RB, LT, RT, Back or Select, Start, Home,
+// LS, RS, Up, Down, Left, Right), "Gamepad:Button<N>" (raw index) or
+// "Gamepad:LeftStickUp" etc. Names are case-insensitive.
+func parseBinding(name string) (binding, error) {
+	const prefix = "gamepad:"
+	if len(name) <= len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+		var k ebiten.Key
+		if err := k.UnmarshalText([]byte(name)); err != nil {
+			return nil, err
+		}
+		return keyBinding(k), nil
+	}
+	pad := strings.ToLower(name[len(prefix):])
+	if b, ok := standardButtonNames[pad]; ok {
+		return standardButtonBinding(b), nil
+	}
+	if b, ok := stickNames[pad]; ok {
+		return b, nil
+	}
+	if n, ok := strings.CutPrefix(pad, "button"); ok {
+		if i, err := strconv.Atoi(n); err == nil && i >= 0 && i <= int(ebiten.GamepadButtonMax) {
+			return buttonBinding(i), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown gamepad button %q", name)
+}
+
+// parseBindings reads the bindings of an action. Empty names are skipped.
+func parseBindings(names config.Bindings) ([]binding, error) {
+	var bindings []binding
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		b, err := parseBinding(name)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, nil
+}
+
+func isAnyPressed(bindings []binding, pad gamepad) bool {
+	for _, b := range bindings {
+		if b.isPressed(pad) {
+			return true
+		}
+	}
+	return false
+}
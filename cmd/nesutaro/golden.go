@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"nesutaro/internal/emulator"
+	"nesutaro/internal/ppu/filter"
+	"nesutaro/internal/ppu/palette"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runGolden implements "nesutaro golden": it renders every ROM in a directory
+// headlessly and records or verifies reference screenshots.
+//
+//	<romdir>/<name>.nes           ROM
+//	<romdir>/<name>.input         optional input script (see emulator.ParseInputScript)
+//	<romdir>/golden/<name>.png    reference screenshot
+//	<romdir>/golden/<name>.diff.png  written on mismatch
+//
+// With filter flags, the goldens are <name>.<filters>.png, e.g. <name>.composite+hq2x.png.
+func runGolden(args []string) int {
+	fs := flag.NewFlagSet("golden", flag.ExitOnError)
+	record := fs.Bool("record", false, "write the rendered frames as new goldens")
+	frames := fs.Int("frames", 300, "number of frames to run each ROM")
+	var opts filter.Options
+	fs.StringVar(&opts.Signal, "signal", "", "signal filter: composite, svideo or rgb")
+	fs.StringVar(&opts.Scaler, "scaler", "", "scaler: scale2x, scale3x, hq2x or xbr")
+	fs.Float64Var(&opts.Scanlines, "scanlines", 0, "scanline darkening, 0.0 ~ 1.0")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: nesutaro golden [-record] [-frames N] [-signal S] [-scaler S] [-scanlines N] <romdir>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	opts.NTSC = palette.DefaultNTSCParams()
+	if _, err := filter.New(opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	romDir := fs.Arg(0)
+	goldenDir := filepath.Join(romDir, "golden")
+
+	roms, err := filepath.Glob(filepath.Join(romDir, "*.nes"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if len(roms) == 0 {
+		fmt.Fprintf(os.Stderr, "no .nes files in %s\n", romDir)
+		return 2
+	}
+	if *record {
+		if err := os.MkdirAll(goldenDir, 0755); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	failed := 0
+	for _, romPath := range roms {
+		name := strings.TrimSuffix(filepath.Base(romPath), filepath.Ext(romPath))
+		if err := goldenROM(romPath, name, goldenDir, *frames, *record, opts); err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+			failed++
+		} else if *record {
+			fmt.Printf("REC  %s\n", name)
+		} else {
+			fmt.Printf("ok   %s\n", name)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d ROMs failed\n", failed, len(roms))
+		return 1
+	}
+	return 0
+}
+
+func goldenROM(romPath, name, goldenDir string, frames int, record bool, opts filter.Options) error {
+	rom, err := os.ReadFile(romPath)
+	if err != nil {
+		return err
+	}
+
+	var script emulator.InputScript
+	inputPath := filepath.Join(filepath.Dir(romPath), name+".input")
+	if f, err := os.Open(inputPath); err == nil {
+		script, err = emulator.ParseInputScript(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", inputPath, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// A filter keeps state between frames, so each ROM gets its own.
+	var f *filter.Pipeline
+	goldenName := name
+	if opts.Name() != "" {
+		f, _ = filter.New(opts)
+		goldenName += "." + opts.Name()
+	}
+	got, err := emulator.RunHeadless(rom, frames, script, f)
+	if err != nil {
+		return err
+	}
+
+	goldenPath := filepath.Join(goldenDir, goldenName+".png")
+	diffPath := filepath.Join(goldenDir, goldenName+".diff.png")
+	if record {
+		os.Remove(diffPath)
+		return emulator.SavePNG(goldenPath, got)
+	}
+
+	want, err := emulator.LoadPNG(goldenPath)
+	if err != nil {
+		return err
+	}
+	mismatches, diff := emulator.CompareFrames(got, want)
+	if mismatches == 0 {
+		os.Remove(diffPath)
+		return nil
+	}
+	if err := emulator.SavePNG(diffPath, diff); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d pixels differ (see %s)", mismatches, diffPath)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"nesutaro/config"
+	"nesutaro/internal/joypad"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ebitenInput implements joypad.InputSource with Ebiten keyboard/gamepad.
+// It implements joypad.TurboSource with the turbo A/B bindings.
+type ebitenInput struct {
+	buttons [8][]binding // From config.toml
+	turbo   [2][]binding // A, B (from config.toml)
+	pad     gamepad      // From config.toml
+}
+
+// newEbitenInputs creates the input sources of players 1 ~ 4.
+func newEbitenInputs(cfg *config.Config) ([4]*ebitenInput, error) {
+	var inputs [4]*ebitenInput
+	for i, p := range cfg.Players() {
+		in := &ebitenInput{pad: newGamepad(p)}
+		for j, names := range p.Buttons() {
+			var err error
+			if in.buttons[j], err = parseBindings(names); err != nil {
+				return inputs, fmt.Errorf("player%d.%s: %w", i+1, buttonConfigNames[j], err)
+			}
+		}
+		var err error
+		if in.turbo[0], err = parseBindings(p.TurboA); err != nil {
+			return inputs, fmt.Errorf("player%d.turbo_a: %w", i+1, err)
+		}
+		if in.turbo[1], err = parseBindings(p.TurboB); err != nil {
+			return inputs, fmt.Errorf("player%d.turbo_b: %w", i+1, err)
+		}
+		inputs[i] = in
+	}
+	return inputs, nil
+}
+
+// Keys of the buttons in the [playerN] tables, in joypad bit order
+var buttonConfigNames = [8]string{"a", "b", "select", "start", "up", "down", "left", "right"}
+
+func newGamepad(p config.PlayerConfig) gamepad {
+	return gamepad{id: ebiten.GamepadID(p.Gamepad), deadzone: p.Deadzone}
+}
+
+func (in *ebitenInput) Poll() byte {
+	var buttons byte
+	for i, b := range in.buttons {
+		if isAnyPressed(b, in.pad) {
+			buttons |= 1 << i
+		}
+	}
+	return buttons
+}
+
+func (in *ebitenInput) PollTurbo() byte {
+	var buttons byte
+	for i, b := range in.turbo {
+		if isAnyPressed(b, in.pad) {
+			buttons |= 1 << i
+		}
+	}
+	return buttons
+}
+
+// turboPeriod returns the frames of a turbo cycle at the given presses per second.
+func turboPeriod(rate float64, frameRate int) int {
+	if rate <= 0 {
+		return 2
+	}
+	return int(math.Round(float64(frameRate) / rate))
+}
+
+// ebitenZapper implements joypad.ZapperSource with the mouse.
+// The cursor aims at the game screen and the left button pulls the trigger.
+type ebitenZapper struct {
+	g *Game
+}
+
+func (z ebitenZapper) Poll() (int, int, bool) {
+	w, h := z.g.gameAreaSize()
+	cx, cy := ebiten.CursorPosition()
+	x, y := z.g.layout.toFrame(cx, cy, w, h)
+	if !image.Pt(x, y).In(z.g.layout.srcRect) {
+		x, y = -1, -1 // Cropped by the overscan, or outside the screen
+	}
+	return x, y, ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+}
+
+// ebitenVaus implements joypad.VausSource with the mouse or the left stick.
+// The knob follows the cursor across the game screen while the mouse moves,
+// and turns with the stick otherwise.
+type ebitenVaus struct {
+	g           *Game
+	pad         gamepad // From config.toml
+	pos         float64
+	prevCursorX int
+}
+
+// Full turn of the knob in 1 second with the stick fully tilted
+const vausStickSpeed = 1.0 / 60
+
+func (v *ebitenVaus) Poll() (float64, bool) {
+	cx, _ := ebiten.CursorPosition()
+	if cx != v.prevCursorX {
+		v.prevCursorX = cx
+		w, h := v.g.gameAreaSize()
+		x, _ := v.g.layout.toFrame(cx, 0, w, h)
+		r := v.g.layout.srcRect
+		v.pos = float64(x-r.Min.X) / float64(r.Dx()-1)
+	}
+	axis := ebiten.StandardGamepadAxisValue(v.pad.id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	if math.Abs(axis) > v.pad.deadzone {
+		v.pos += axis * vausStickSpeed
+	}
+	isButton := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) ||
+		ebiten.IsStandardGamepadButtonPressed(v.pad.id, ebiten.StandardGamepadButtonRightBottom)
+	v.pos = min(max(v.pos, 0), 1)
+	return v.pos, isButton
+}
+
+// noKey leaves a mat button without a key.
+const noKey ebiten.Key = -1
+
+// ebitenMat implements joypad.MatSource with a grid of keys.
+type ebitenMat struct {
+	keys [12]ebiten.Key // From config.toml
+}
+
+func newEbitenMat(cfg config.PowerPadConfig) (*ebitenMat, error) {
+	m := &ebitenMat{}
+	for i, name := range cfg.Keys {
+		m.keys[i] = noKey
+		if name == "" {
+			continue
+		}
+		if err := m.keys[i].UnmarshalText([]byte(name)); err != nil {
+			return nil, fmt.Errorf("powerpad.keys: %w", err)
+		}
+	}
+	return m, nil
+}
+
+func (m *ebitenMat) Poll() uint16 {
+	var buttons uint16
+	for i, k := range m.keys {
+		if k != noKey && ebiten.IsKeyPressed(k) {
+			buttons |= 1 << i
+		}
+	}
+	return buttons
+}
+
+// Host keys of the Family BASIC keyboard matrix (see joypad.KeyMatrix),
+// [row][column][D1 ~ D4]. Keys missing from a US layout are moved nearby:
+// STOP = End, ¥ = Backslash, KANA = Right Alt, @ = Backquote, ^ = Equal,
+// _ = Intl Backslash, GRPH = Left Alt, CLR HOME = Home, DEL = Backspace.
+var familyKeyboardKeys = [9][2][4]ebiten.Key{
+	{{ebiten.KeyF8, ebiten.KeyEnter, ebiten.KeyBracketLeft, ebiten.KeyBracketRight},
+		{ebiten.KeyAltRight, ebiten.KeyShiftRight, ebiten.KeyBackslash, ebiten.KeyEnd}},
+	{{ebiten.KeyF7, ebiten.KeyBackquote, ebiten.KeyQuote, ebiten.KeySemicolon},
+		{ebiten.KeyIntlBackslash, ebiten.KeySlash, ebiten.KeyMinus, ebiten.KeyEqual}},
+	{{ebiten.KeyF6, ebiten.KeyO, ebiten.KeyL, ebiten.KeyK},
+		{ebiten.KeyPeriod, ebiten.KeyComma, ebiten.KeyP, ebiten.KeyDigit0}},
+	{{ebiten.KeyF5, ebiten.KeyI, ebiten.KeyU, ebiten.KeyJ},
+		{ebiten.KeyM, ebiten.KeyN, ebiten.KeyDigit9, ebiten.KeyDigit8}},
+	{{ebiten.KeyF4, ebiten.KeyY, ebiten.KeyG, ebiten.KeyH},
+		{ebiten.KeyB, ebiten.KeyV, ebiten.KeyDigit7, ebiten.KeyDigit6}},
+	{{ebiten.KeyF3, ebiten.KeyT, ebiten.KeyR, ebiten.KeyD},
+		{ebiten.KeyF, ebiten.KeyC, ebiten.KeyDigit5, ebiten.KeyDigit4}},
+	{{ebiten.KeyF2, ebiten.KeyW, ebiten.KeyS, ebiten.KeyA},
+		{ebiten.KeyX, ebiten.KeyZ, ebiten.KeyE, ebiten.KeyDigit3}},
+	{{ebiten.KeyF1, ebiten.KeyEscape, ebiten.KeyQ, ebiten.KeyControlLeft},
+		{ebiten.KeyShiftLeft, ebiten.KeyAltLeft, ebiten.KeyDigit1, ebiten.KeyDigit2}},
+	{{ebiten.KeyHome, ebiten.KeyUp, ebiten.KeyRight, ebiten.KeyLeft},
+		{ebiten.KeyDown, ebiten.KeySpace, ebiten.KeyBackspace, ebiten.KeyInsert}},
+}
+
+// ebitenKeyboard implements joypad.KeyboardSource with the host keyboard.
+type ebitenKeyboard struct{}
+
+func (ebitenKeyboard) Poll() joypad.KeyMatrix {
+	var m joypad.KeyMatrix
+	for row, columns := range familyKeyboardKeys {
+		for col, keys := range columns {
+			for bit, k := range keys {
+				if ebiten.IsKeyPressed(k) {
+					m[row][col] |= 1 << bit
+				}
+			}
+		}
+	}
+	return m
+}
+
+// Emulator controls, in the order of the [hotkeys] table.
+// The macro keys follow them.
+const (
+	hotkeyPause = iota
+	hotkeyStep
+	hotkeyQuit
+	hotkeyFullscreen
+	hotkeyRecordMacro
+	hotkeyPlayMacro
+	hotkeyRecordMovie
+	hotkeyRecordMovieFromState
+	hotkeyPlayMovie
+	hotkeyCount
+)
+
+// ebitenHotkeys turns the emulator control bindings into edge-triggered hotkeys.
+type ebitenHotkeys struct {
+	bindings [][]binding // From config.toml
+	pad      gamepad     // Player 1's gamepad
+	isPrev   []bool
+	// Keys are ignored while the Family BASIC keyboard takes the whole keyboard.
+	isKeyboardCaptured bool
+}
+
+func newEbitenHotkeys(cfg *config.Config) (ebitenHotkeys, error) {
+	h := ebitenHotkeys{pad: newGamepad(cfg.Player1)}
+	hk := cfg.Hotkeys
+	for _, names := range [hotkeyCount]config.Bindings{
+		hk.Pause, hk.Step, hk.Quit, hk.Fullscreen, hk.RecordMacro, hk.PlayMacro,
+		hk.RecordMovie, hk.RecordMovieFromState, hk.PlayMovie,
+	} {
+		bindings, err := parseBindings(names)
+		if err != nil {
+			return h, fmt.Errorf("hotkeys: %w", err)
+		}
+		h.add(bindings)
+	}
+	return h, nil
+}
+
+// add appends a hotkey and returns its index in the result of poll.
+func (h *ebitenHotkeys) add(bindings []binding) int {
+	h.bindings = append(h.bindings, bindings)
+	h.isPrev = append(h.isPrev, false)
+	return len(h.bindings) - 1
+}
+
+// poll returns the hotkeys pressed since the previous call.
+func (h *ebitenHotkeys) poll() []bool {
+	keys := make([]bool, len(h.bindings))
+	for i, bindings := range h.bindings {
+		isPressed := false
+		for _, b := range bindings {
+			if _, isKey := b.(keyBinding); isKey && h.isKeyboardCaptured {
+				continue
+			}
+			isPressed = isPressed || b.isPressed(h.pad)
+		}
+		keys[i] = !h.isPrev[i] && isPressed
+		h.isPrev[i] = isPressed
+	}
+	return keys
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"nesutaro/internal/joypad"
+	"os"
+)
+
+// macroKey plays a macro file on a player's controller (from config.toml).
+type macroKey struct {
+	hotkey int // Index in the result of ebitenHotkeys.poll
+	player int
+	macro  joypad.Macro
+}
+
+// loadMacros reads the macro files and binds their keys as hotkeys.
+// The last recording is reloaded from the macro file, if any.
+func (g *Game) loadMacros() error {
+	for i, m := range g.cfg.Macros {
+		player := max(m.Player, 1) - 1
+		if player > 3 {
+			return fmt.Errorf("macro %d: no player %d", i+1, m.Player)
+		}
+		macro, err := loadMacro(m.File)
+		if err != nil {
+			return fmt.Errorf("macro %d: %w", i+1, err)
+		}
+		bindings, err := parseBindings(m.Key)
+		if err != nil {
+			return fmt.Errorf("macro %d: %w", i+1, err)
+		}
+		g.macros = append(g.macros, macroKey{hotkey: g.hotkeys.add(bindings), player: player, macro: macro})
+	}
+	if path := g.cfg.Input.MacroFile; path != "" {
+		macro, err := loadMacro(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		g.recordedMacro = macro
+	}
+	return nil
+}
+
+func loadMacro(path string) (joypad.Macro, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := joypad.ParseMacro(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+func saveMacro(path string, m joypad.Macro) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := m.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// updateMacros records and plays macros with the hotkeys of this frame.
+func (g *Game) updateMacros(keys []bool) {
+	p1 := g.emu.Joypad(0)
+	if keys[hotkeyRecordMacro] {
+		if p1.IsRecording() {
+			g.recordedMacro = p1.StopRecording()
+			log.Printf("Recorded a macro of %d frames", len(g.recordedMacro))
+			if path := g.cfg.Input.MacroFile; path != "" {
+				if err := saveMacro(path, g.recordedMacro); err != nil {
+					log.Println(err)
+				}
+			}
+		} else {
+			p1.StartRecording()
+		}
+	}
+	if keys[hotkeyPlayMacro] && !p1.IsRecording() {
+		p1.PlayMacro(g.recordedMacro)
+	}
+	for _, m := range g.macros {
+		if keys[m.hotkey] {
+			g.emu.Joypad(m.player).PlayMacro(m.macro)
+		}
+	}
+}
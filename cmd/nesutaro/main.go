@@ -2,13 +2,17 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
 	"log"
 	"nesutaro/config"
 	"nesutaro/internal/emulator"
+	"nesutaro/internal/joypad"
+	"nesutaro/internal/ppu/filter"
+	"nesutaro/internal/ppu/palette"
+	"nesutaro/internal/region"
 	"os"
 
 	"path/filepath"
@@ -20,16 +24,24 @@ import (
 )
 
 var screenFont *text.GoTextFaceSource
-var srcRect = image.Rect(0, 8, 256, 240-8)
-var dstRect = image.Rect(0, 0, 256, 240-16)
+
+// Width of the debug screen at scale 1
+const debuggerWidth = 256
 
 type Game struct {
 	emu                  *emulator.Emulator
 	ebitenImage          *ebiten.Image
-	imageRGBA            *image.RGBA
 	audioCtx             *audio.Context
 	audioPlayer          *audio.Player
+	hotkeys              ebitenHotkeys
+	macros               []macroKey
+	recordedMacro        joypad.Macro // Played by the play_macro hotkey
 	cfg                  *config.Config
+	romPath              string
+	initialMovie         string // Played from power-on (from the command line)
+	layout               videoLayout
+	screenWidth          int // From Layout()
+	screenHeight         int
 	pixelScale           int
 	isDebugScreenEnabled bool
 	debugLog             []string
@@ -38,17 +50,47 @@ type Game struct {
 func newGame(g *Game, rom /* , sav */ []byte) *Game {
 	screenFont, _ = text.NewGoTextFaceSource(bytes.NewReader(fonts.PressStart2P_ttf))
 
-	debuggerWidth := 0
-	if g.isDebugScreenEnabled {
-		debuggerWidth = 256
+	g.emu = emulator.NewEmulator(rom /* , sav */)
+
+	r, err := detectRegion(g.cfg.System, rom)
+	if err != nil {
+		log.Fatal(err)
 	}
-	g.imageRGBA = image.NewRGBA(image.Rect(0, 0, 256+debuggerWidth, 224))
-	g.ebitenImage = ebiten.NewImage(256+debuggerWidth, 224)
+	g.emu.SetRegion(r)
+	ebiten.SetTPS(r.Timing().FrameRate)
 
-	g.emu = emulator.NewEmulator(rom /* , sav */)
+	pal, err := loadPalette(g.cfg.Video)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.emu.Palette = pal
+	if g.emu.Filter, err = newFilter(g.cfg.Video); err != nil {
+		log.Fatal(err)
+	}
+	g.emu.CPU.Bus.PPU.SetIsSpriteLimitDisabled(g.cfg.Video.IsSpriteLimitDisabled)
 
-	g.emu.CPU.Bus.Joypad.SetIsGamepadEnabled(g.cfg.Gamepad.IsEnabled)
-	g.emu.CPU.Bus.Joypad.SetIsGamepadBind(g.cfg.Gamepad.Bind)
+	if g.hotkeys, err = newEbitenHotkeys(g.cfg); err != nil {
+		log.Fatal(err)
+	}
+	if err := g.loadMacros(); err != nil {
+		log.Fatal(err)
+	}
+	inputs, err := newEbitenInputs(g.cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i, in := range inputs {
+		g.emu.Joypad(i).SetInputSource(in)
+		g.emu.Joypad(i).SetTurboPeriod(turboPeriod(g.cfg.Players()[i].TurboRate, r.Timing().FrameRate))
+	}
+	if err := g.plugDevices(); err != nil {
+		log.Fatal(err)
+	}
+	if g.initialMovie != "" {
+		if err := g.playMovie(g.initialMovie); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	/* g.audioCtx = audio.NewContext(int(apu.SampleRate))
 	g.audioPlayer, _ = g.audioCtx.NewPlayerF32(g.emu.CPU.Bus.APU.AudioStream)
@@ -68,6 +110,17 @@ func (g *Game) Update() error {
 		g.audioPlayer.Play()
 	} */
 	if ebiten.IsFocused() {
+		keys := g.hotkeys.poll()
+		if keys[hotkeyFullscreen] {
+			ebiten.SetFullscreen(!ebiten.IsFullscreen())
+		}
+		g.updateMacros(keys)
+		g.updateMovie(keys)
+		g.emu.SetHotkeys(emulator.Hotkeys{
+			TogglePause: keys[hotkeyPause],
+			Step:        keys[hotkeyStep],
+			Quit:        keys[hotkeyQuit],
+		})
 		if g.emu.RunFrame() == -1 {
 			return ebiten.Termination
 		}
@@ -76,65 +129,89 @@ func (g *Game) Update() error {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	gameScreen := g.emu.CPU.Bus.PPU.GetGameScreen()
-	draw.Draw(g.imageRGBA, dstRect, gameScreen, srcRect.Min, draw.Src)
-	g.ebitenImage = ebiten.NewImageFromImage(g.imageRGBA)
+	gameScreen := g.emu.GetGameScreen()
+	// Filters output the frame magnified.
+	sx, sy := gameScreen.Bounds().Dx()/256, gameScreen.Bounds().Dy()/240
+	r := g.layout.srcRect
+	srcRect := image.Rect(r.Min.X*sx, r.Min.Y*sy, r.Max.X*sx, r.Max.Y*sy)
+	// The image is allocated once and updated in place.
+	if g.ebitenImage == nil || g.ebitenImage.Bounds() != gameScreen.Bounds() {
+		g.ebitenImage = ebiten.NewImage(gameScreen.Bounds().Dx(), gameScreen.Bounds().Dy())
+	}
+	g.ebitenImage.WritePixels(gameScreen.Pix)
 
+	// The game screen is scaled to fit the window, left of the debug screen.
+	gameWidth, _ := g.gameAreaSize()
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Scale(float64(g.pixelScale), float64(g.pixelScale))
-	screen.DrawImage(g.ebitenImage, op)
+	op.GeoM.Scale(1/float64(sx), 1/float64(sy))
+	op.GeoM.Concat(g.layout.geoM(gameWidth, screen.Bounds().Dy()))
+	op.Filter = g.layout.filter(op.GeoM)
+	screen.DrawImage(g.ebitenImage.SubImage(srcRect).(*ebiten.Image), op)
+	g.drawMovieStatus(screen)
 
 	if g.isDebugScreenEnabled {
 		strs := g.emu.GetDebugLog()
 		for i, s := range strs {
 			white := color.RGBA{255, 255, 255, 255}
 			fontSize := 16
-			g.drawText(screen, s, 256*g.pixelScale+fontSize, (i+1)*fontSize, fontSize, white)
+			g.drawText(screen, s, gameWidth+fontSize, (i+1)*fontSize, fontSize, white)
 		}
 	}
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	screenHeight := 224 * g.pixelScale
-	screenWidth := 256 * g.pixelScale
+	g.screenWidth, g.screenHeight = outsideWidth, outsideHeight
+	return outsideWidth, outsideHeight
+}
+
+// gameAreaSize returns the size of the window area the game screen is fitted in.
+func (g *Game) gameAreaSize() (int, int) {
+	w := g.screenWidth
 	if g.isDebugScreenEnabled {
-		screenWidth *= 2
+		w -= debuggerWidth * g.pixelScale
 	}
-
-	return screenWidth, screenHeight
+	return w, g.screenHeight
 }
 
 func main() {
+	if len(os.Args) >= 2 && os.Args[1] == "golden" {
+		os.Exit(runGolden(os.Args[2:]))
+	}
+
 	g := &Game{}
 
 	var err error
 	if g.cfg, err = config.Load("config.toml"); err != nil {
 		panic(err)
 	}
-	g.pixelScale = g.cfg.Video.Scale
-	g.pixelScale = max(g.pixelScale, 1)
-	g.pixelScale = min(g.pixelScale, 4)
+	g.pixelScale = max(g.cfg.Video.Scale, 1)
 	g.isDebugScreenEnabled = g.cfg.Video.IsShowDebug
+	g.layout = newVideoLayout(g.cfg.Video)
 
 	if len(os.Args) < 2 {
-		fmt.Println("usage: nesutaro <romfile>")
+		fmt.Println("usage: nesutaro <romfile> [movie.fm2]")
+		fmt.Println("       nesutaro golden [-record] [-frames N] [-signal S] [-scaler S] [-scanlines N] <romdir>")
 		return
 	}
-	romPath := os.Args[1]
-	rom, err := os.ReadFile(romPath)
+	g.romPath = os.Args[1]
+	if len(os.Args) >= 3 {
+		g.initialMovie = os.Args[2]
+	}
+	rom, err := os.ReadFile(g.romPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	/* savPath := getSavePathFromROM(romPath)
+	/* savPath := getSavePathFromROM(g.romPath)
 	sav, _ := os.ReadFile(savPath) */
 
-	windowHeight := 224 * g.pixelScale
-	windowWidth := 256 * g.pixelScale
+	windowWidth, windowHeight := g.layout.windowSize(g.pixelScale)
 	if g.isDebugScreenEnabled {
-		windowWidth *= 2
+		windowWidth += debuggerWidth * g.pixelScale
 	}
 	ebiten.SetWindowSize(windowWidth, windowHeight)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetFullscreen(g.cfg.Video.IsFullscreen)
 
 	err = ebiten.RunGame(newGame(g, rom /* , sav */))
 	if err != nil && err != ebiten.Termination {
@@ -158,6 +235,110 @@ func (g *Game) drawText(dst *ebiten.Image, msg string, x, y, size int, cr color.
 	}, op)
 }
 
+// From config.toml. A multitap takes both ports.
+func (g *Game) plugDevices() error {
+	in := g.cfg.Input
+	multitap, err := joypad.ParseMultitap(in.Multitap)
+	if err != nil {
+		return err
+	}
+	g.emu.ConnectControllers(multitap)
+	if multitap != joypad.NoMultitap {
+		return nil
+	}
+	for i, name := range []string{in.Port1, in.Port2} {
+		switch name {
+		case "", "joypad":
+			g.emu.Plug(i, g.emu.Joypad(i))
+		case "zapper":
+			z := g.emu.NewZapper()
+			z.SetInputSource(ebitenZapper{g})
+			g.emu.Plug(i, z)
+		case "vaus":
+			v := joypad.NewVaus()
+			v.SetInputSource(&ebitenVaus{g: g, pad: newGamepad(g.cfg.Players()[i]), pos: 0.5})
+			g.emu.Plug(i, v)
+		case "powerpad", "familytrainer":
+			mat, err := newEbitenMat(g.cfg.PowerPad)
+			if err != nil {
+				return err
+			}
+			p := joypad.NewPowerPad()
+			if name == "familytrainer" {
+				p = joypad.NewFamilyTrainer()
+			}
+			p.SetInputSource(mat)
+			g.emu.Plug(i, p)
+		case "keyboard":
+			k := joypad.NewKeyboard()
+			k.SetInputSource(ebitenKeyboard{})
+			g.emu.Plug(i, k)
+			g.hotkeys.isKeyboardCaptured = true
+		case "none":
+			g.emu.Plug(i, nil)
+		default:
+			return fmt.Errorf("input.port%d: unknown device %q", i+1, name)
+		}
+	}
+	return nil
+}
+
+// From config.toml
+func loadPalette(cfg config.VideoConfig) (*palette.Palette, error) {
+	switch cfg.Palette {
+	case "":
+		return palette.Default(), nil
+	case "ntsc":
+		return palette.GenerateNTSC(palette.NTSCParams{
+			Hue:        cfg.NTSCPalette.Hue,
+			Saturation: cfg.NTSCPalette.Saturation,
+			Contrast:   cfg.NTSCPalette.Contrast,
+			Brightness: cfg.NTSCPalette.Brightness,
+			Gamma:      cfg.NTSCPalette.Gamma,
+		}), nil
+	default:
+		return palette.LoadFile(cfg.Palette)
+	}
+}
+
+// From config.toml
+func detectRegion(cfg config.SystemConfig, rom []byte) (region.Region, error) {
+	if cfg.Region != "" {
+		return region.Parse(cfg.Region)
+	}
+	var db region.DB
+	if cfg.RegionDB != "" {
+		var err error
+		db, err = region.LoadDBFile(cfg.RegionDB)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return region.NTSC, fmt.Errorf("%s: %w", cfg.RegionDB, err)
+		}
+	}
+	r, source := region.Detect(rom, db)
+	log.Printf("Region = %s (%s)", r, source)
+	return r, nil
+}
+
+// From config.toml. nil when no filter is enabled.
+func newFilter(cfg config.VideoConfig) (*filter.Pipeline, error) {
+	opts := filter.Options{
+		Signal:    cfg.Filter.Signal,
+		Scaler:    cfg.Filter.Scaler,
+		Scanlines: cfg.Filter.Scanlines,
+		NTSC: palette.NTSCParams{
+			Hue:        cfg.NTSCPalette.Hue,
+			Saturation: cfg.NTSCPalette.Saturation,
+			Contrast:   cfg.NTSCPalette.Contrast,
+			Brightness: cfg.NTSCPalette.Brightness,
+			Gamma:      cfg.NTSCPalette.Gamma,
+		},
+	}
+	if opts.Name() == "" {
+		return nil, nil
+	}
+	return filter.New(opts)
+}
+
 func getSavePathFromROM(romPath string) string {
 	ext := filepath.Ext(romPath)
 	base := romPath[:len(romPath)-len(ext)]
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"nesutaro/internal/movie"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// moviePath returns the FM2 file of the movie hotkeys (from config.toml).
+func (g *Game) moviePath() string {
+	if g.cfg.Input.MovieFile != "" {
+		return g.cfg.Input.MovieFile
+	}
+	ext := filepath.Ext(g.romPath)
+	return g.romPath[:len(g.romPath)-len(ext)] + ".fm2"
+}
+
+func loadMovie(path string) (*movie.Movie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return movie.Read(f)
+}
+
+func saveMovie(path string, m *movie.Movie) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// playMovie starts playing a movie file.
+func (g *Game) playMovie(path string) error {
+	m, err := loadMovie(path)
+	if err != nil {
+		return err
+	}
+	if err := g.emu.PlayMovie(m); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	log.Printf("Playing %s (%d frames)", path, len(m.Frames))
+	return nil
+}
+
+// stopMovie ends the movie. A recording is saved.
+func (g *Game) stopMovie() {
+	isRecording := g.emu.MovieStatus().IsRecording
+	m := g.emu.StopMovie()
+	if !isRecording {
+		return
+	}
+	m.ROMFilename = filepath.Base(g.romPath)
+	path := g.moviePath()
+	if err := saveMovie(path, m); err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("Recorded %s (%d frames)", path, len(m.Frames))
+}
+
+// updateMovie starts and stops movies with the hotkeys of this frame.
+func (g *Game) updateMovie(keys []bool) {
+	s := g.emu.MovieStatus()
+	isRecord := keys[hotkeyRecordMovie] || keys[hotkeyRecordMovieFromState]
+	switch {
+	case (s.IsRecording || s.IsPlaying) && (isRecord || keys[hotkeyPlayMovie]):
+		g.stopMovie()
+	case isRecord:
+		if err := g.emu.RecordMovie(keys[hotkeyRecordMovie]); err != nil {
+			log.Println(err)
+		}
+	case keys[hotkeyPlayMovie]:
+		if err := g.playMovie(g.moviePath()); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// drawMovieStatus shows the movie and the frame counters over the game screen.
+func (g *Game) drawMovieStatus(screen *ebiten.Image) {
+	s := g.emu.MovieStatus()
+	var msg string
+	switch {
+	case s.IsRecording:
+		msg = fmt.Sprintf("REC %d", s.Frame)
+	case s.IsPlaying:
+		msg = fmt.Sprintf("PLAY %d/%d", s.Frame, s.Length)
+	default:
+		return
+	}
+	frames, lag := g.emu.FrameCounters()
+	msg += fmt.Sprintf("  FRAME %d  LAG %d", frames, lag)
+	fontSize := 8 * g.pixelScale
+	g.drawText(screen, msg, fontSize, 2*fontSize, fontSize, color.RGBA{255, 255, 255, 255})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"image"
+	"math"
+	"nesutaro/config"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// videoLayout places the cropped game screen in the window (from config.toml).
+type videoLayout struct {
+	srcRect          image.Rectangle // Visible part of the 256x240 frame
+	pixelAspect      float64         // Pixel width / height
+	isIntegerScaling bool
+}
+
+func newVideoLayout(cfg config.VideoConfig) videoLayout {
+	o := cfg.Overscan
+	clamp := func(v int) int { return min(max(v, 0), 64) }
+	l := videoLayout{
+		srcRect:          image.Rect(clamp(o.Left), clamp(o.Top), 256-clamp(o.Right), 240-clamp(o.Bottom)),
+		pixelAspect:      1.0,
+		isIntegerScaling: cfg.IsIntegerScaling,
+	}
+	if cfg.IsAspectCorrected {
+		l.pixelAspect = 8.0 / 7.0 // NTSC pixels are slightly wider than tall.
+	}
+	return l
+}
+
+// size returns the game screen size at scale 1.
+func (l videoLayout) size() (float64, float64) {
+	return float64(l.srcRect.Dx()) * l.pixelAspect, float64(l.srcRect.Dy())
+}
+
+// windowSize returns the game screen size at the given scale.
+func (l videoLayout) windowSize(scale int) (int, int) {
+	w, h := l.size()
+	return int(math.Round(w * float64(scale))), int(h) * scale
+}
+
+// geoM scales the cropped screen to fit a w x h area, centered.
+// With integer scaling, the vertical scale is an integer
+// (the horizontal one too, unless the aspect ratio is corrected).
+func (l videoLayout) geoM(w, h int) ebiten.GeoM {
+	sw, sh := l.size()
+	scale := min(float64(w)/sw, float64(h)/sh)
+	if l.isIntegerScaling && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	var geoM ebiten.GeoM
+	geoM.Scale(scale*l.pixelAspect, scale)
+	geoM.Translate(math.Floor((float64(w)-sw*scale)/2), math.Floor((float64(h)-sh*scale)/2))
+	return geoM
+}
+
+// toFrame maps a point of the w x h area back to the 256x240 frame.
+// It is the inverse of geoM.
+func (l videoLayout) toFrame(x, y, w, h int) (int, int) {
+	geoM := l.geoM(w, h)
+	geoM.Invert()
+	fx, fy := geoM.Apply(float64(x), float64(y))
+	return int(math.Floor(fx)) + l.srcRect.Min.X, int(math.Floor(fy)) + l.srcRect.Min.Y
+}
+
+// filter returns the filter to draw with geoM: nearest under integer scaling
+// if both scales are whole numbers, else linear.
+// A scaler's output may still be scaled by a fraction (e.g. 2x shown at 3x).
+func (l videoLayout) filter(geoM ebiten.GeoM) ebiten.Filter {
+	isWhole := func(v float64) bool { return v == math.Trunc(v) }
+	if l.isIntegerScaling && isWhole(geoM.Element(0, 0)) && isWhole(geoM.Element(1, 1)) {
+		return ebiten.FilterNearest
+	}
+	return ebiten.FilterLinear
+}
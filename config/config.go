@@ -1,26 +1,351 @@
 package config
 
-import "github.com/BurntSushi/toml"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
 
 func Load(path string) (*Config, error) {
-	var cfg Config
+	cfg := Config{
+		System: SystemConfig{
+			RegionDB: "romdb.txt",
+		},
+		Video: VideoConfig{
+			IsIntegerScaling: true,
+			Overscan: OverscanConfig{
+				Top:    8,
+				Bottom: 8,
+			},
+			NTSCPalette: NTSCPaletteConfig{
+				Saturation: 1.0,
+				Contrast:   1.0,
+				Gamma:      2.2,
+			},
+		},
+		Hotkeys: HotkeysConfig{
+			Pause:                Bindings{"P"},
+			Step:                 Bindings{"S"},
+			Quit:                 Bindings{"Escape"},
+			Fullscreen:           Bindings{"F11"},
+			RecordMacro:          Bindings{"F9"},
+			PlayMacro:            Bindings{"F10"},
+			RecordMovie:          Bindings{"F7"},
+			RecordMovieFromState: Bindings{"F6"},
+			PlayMovie:            Bindings{"F8"},
+		},
+		Input: InputConfig{
+			MacroFile: "recorded.macro",
+		},
+		Player1: defaultPlayer(0, "Z", "X", "ShiftLeft", "Enter", "ArrowUp", "ArrowDown", "ArrowLeft", "ArrowRight"),
+		Player2: defaultPlayer(1, "G", "F", "R", "T", "I", "K", "J", "L"),
+		Player3: defaultPlayer(2),
+		Player4: defaultPlayer(3),
+		PowerPad: PowerPadConfig{
+			Keys: [12]string{
+				"Numpad7", "Numpad8", "Numpad9", "NumpadSubtract",
+				"Numpad4", "Numpad5", "Numpad6", "NumpadAdd",
+				"Numpad1", "Numpad2", "Numpad3", "NumpadEnter",
+			},
+		},
+	}
+	cfg.Player1.TurboA = append(Bindings{"C"}, cfg.Player1.TurboA...)
+	cfg.Player1.TurboB = append(Bindings{"V"}, cfg.Player1.TurboB...)
 	if _, err := toml.DecodeFile(path, &cfg); err != nil {
 		return nil, err
 	}
+	if err := migrateLegacyBindings(path, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	return &cfg, nil
 }
 
+// Bindings of older versions:
+//
+//	[gamepad]                             # Player 1
+//	enabled = true
+//	id = 0                                # ebiten.GamepadID
+//	bind = [2, 0, 10, 11, 12, 14, 15, 13] # ebiten.GamepadButton of A, B, SELECT, START, UP, DOWN, LEFT, RIGHT
+//
+//	[player2]
+//	keys = ["G", "F", "R", "T", "I", "K", "J", "L"] # Same order, "" = none
+//
+//	[player2.gamepad]                     # Same as [gamepad]
+type legacyConfig struct {
+	Gamepad toml.Primitive `toml:"gamepad"`
+	Player1 legacyPlayer   `toml:"player1"`
+	Player2 legacyPlayer   `toml:"player2"`
+	Player3 legacyPlayer   `toml:"player3"`
+	Player4 legacyPlayer   `toml:"player4"`
+}
+
+type legacyPlayer struct {
+	Keys    *[8]string     `toml:"keys"`
+	Gamepad toml.Primitive `toml:"gamepad"` // A table, or the gamepad ID of the current format
+}
+
+type legacyGamepad struct {
+	IsEnabled bool    `toml:"enabled"`
+	ID        int     `toml:"id"`
+	Bind      *[8]int `toml:"bind"`
+}
+
+// migrateLegacyBindings converts the bindings of older versions into the
+// current ones. Legacy keys replace the key bindings of the buttons, and a
+// legacy gamepad table replaces their gamepad bindings with "Gamepad:Button<N>".
+func migrateLegacyBindings(path string, cfg *Config) error {
+	var legacy legacyConfig
+	md, err := toml.DecodeFile(path, &legacy)
+	if err != nil {
+		return err
+	}
+	players := [4]struct {
+		cfg    *PlayerConfig
+		legacy legacyPlayer
+		table  string
+	}{
+		{&cfg.Player1, legacy.Player1, "player1"},
+		{&cfg.Player2, legacy.Player2, "player2"},
+		{&cfg.Player3, legacy.Player3, "player3"},
+		{&cfg.Player4, legacy.Player4, "player4"},
+	}
+	for i, p := range players {
+		var pad *legacyGamepad
+		prim, key := p.legacy.Gamepad, toml.Key{p.table, "gamepad"}
+		if i == 0 && md.IsDefined("gamepad") {
+			prim, key = legacy.Gamepad, toml.Key{"gamepad"}
+		}
+		if md.Type(key...) == "Hash" {
+			pad = &legacyGamepad{}
+			if err := md.PrimitiveDecode(prim, pad); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		p.cfg.migrate(p.legacy.Keys, pad)
+	}
+	return nil
+}
+
+func (p *PlayerConfig) migrate(keys *[8]string, pad *legacyGamepad) {
+	if keys == nil && pad == nil {
+		return
+	}
+	buttons := p.Buttons()
+	for i, names := range buttons {
+		var keyNames, padNames Bindings
+		for _, name := range names {
+			if isGamepadBinding(name) {
+				padNames = append(padNames, name)
+			} else {
+				keyNames = append(keyNames, name)
+			}
+		}
+		if keys != nil {
+			keyNames = nil
+			if keys[i] != "" {
+				keyNames = Bindings{keys[i]}
+			}
+		}
+		switch {
+		case pad == nil:
+		case !pad.IsEnabled:
+			padNames = nil
+		case pad.Bind != nil:
+			padNames = Bindings{fmt.Sprintf("Gamepad:Button%d", pad.Bind[i])}
+		}
+		buttons[i] = append(keyNames, padNames...)
+	}
+	p.A, p.B, p.Select, p.Start = buttons[0], buttons[1], buttons[2], buttons[3]
+	p.Up, p.Down, p.Left, p.Right = buttons[4], buttons[5], buttons[6], buttons[7]
+	if pad != nil {
+		p.Gamepad = GamepadID(pad.ID)
+	}
+}
+
+func isGamepadBinding(name string) bool {
+	const prefix = "gamepad:"
+	return len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+}
+
 type Config struct {
+	System  SystemConfig  `toml:"system"`
 	Video   VideoConfig   `toml:"video"`
-	Gamepad GamepadConfig `toml:"gamepad"`
+	Input   InputConfig   `toml:"input"`
+	Hotkeys HotkeysConfig `toml:"hotkeys"`
+	Player1 PlayerConfig  `toml:"player1"`
+	Player2 PlayerConfig  `toml:"player2"`
+	Player3 PlayerConfig  `toml:"player3"` // Needs a multitap
+	Player4 PlayerConfig  `toml:"player4"` // Needs a multitap
+	Macros  []MacroConfig `toml:"macro"`
+
+	PowerPad PowerPadConfig `toml:"powerpad"`
+}
+
+type InputConfig struct {
+	Multitap string `toml:"multitap"` // "", "fourscore", "hori" or "hori4"
+	Port1    string `toml:"port1"`    // "joypad" (or ""), "zapper", "vaus", "powerpad", "familytrainer", "keyboard" or "none"
+	Port2    string `toml:"port2"`    // Same as port1. Ignored with a multitap
+
+	MacroFile string `toml:"macro_file"` // Where the record_macro hotkey saves player 1's input
+	MovieFile string `toml:"movie_file"` // FM2 movie of the movie hotkeys ("" = the ROM path with .fm2)
+}
+
+type SystemConfig struct {
+	Region   string `toml:"region"`    // "" = detect, "ntsc", "pal" or "dendy"
+	RegionDB string `toml:"region_db"` // ROM database consulted before the header
 }
 
 type VideoConfig struct {
-	Scale       int  `toml:"scale"`
-	IsShowDebug bool `toml:"show_debug"`
+	Scale                 int               `toml:"scale"`
+	IsFullscreen          bool              `toml:"fullscreen"`
+	IsIntegerScaling      bool              `toml:"integer_scaling"`
+	IsAspectCorrected     bool              `toml:"aspect_ratio_8_7"`
+	IsShowDebug           bool              `toml:"show_debug"`
+	IsSpriteLimitDisabled bool              `toml:"disable_sprite_limit"`
+	Overscan              OverscanConfig    `toml:"overscan"`
+	Palette               string            `toml:"palette"`
+	NTSCPalette           NTSCPaletteConfig `toml:"ntsc_palette"`
+	Filter                FilterConfig      `toml:"filter"`
+}
+
+// CPU-side video filters (see filter.Options)
+type FilterConfig struct {
+	Signal    string  `toml:"signal"`
+	Scaler    string  `toml:"scaler"`
+	Scanlines float64 `toml:"scanlines"`
+}
+
+// Lines/columns cropped from each edge of the 256x240 screen
+type OverscanConfig struct {
+	Top    int `toml:"top"`
+	Bottom int `toml:"bottom"`
+	Left   int `toml:"left"`
+	Right  int `toml:"right"`
+}
+
+// Used when VideoConfig.Palette is "ntsc"
+type NTSCPaletteConfig struct {
+	Hue        float64 `toml:"hue"`
+	Saturation float64 `toml:"saturation"`
+	Contrast   float64 `toml:"contrast"`
+	Brightness float64 `toml:"brightness"`
+	Gamma      float64 `toml:"gamma"`
+}
+
+// Emulator controls. Gamepad bindings use the gamepad of player 1.
+type HotkeysConfig struct {
+	Pause      Bindings `toml:"pause"` // Toggle Run/Pause Mode
+	Step       Bindings `toml:"step"`  // Run a single step while paused
+	Quit       Bindings `toml:"quit"`
+	Fullscreen Bindings `toml:"fullscreen"`
+
+	RecordMacro Bindings `toml:"record_macro"` // Start/stop recording player 1 into InputConfig.MacroFile
+	PlayMacro   Bindings `toml:"play_macro"`   // Play the last recording on player 1
+
+	RecordMovie          Bindings `toml:"record_movie"`            // Start recording from power-on / stop and save
+	RecordMovieFromState Bindings `toml:"record_movie_from_state"` // Start recording from the current state / stop and save
+	PlayMovie            Bindings `toml:"play_movie"`              // Start / stop playing InputConfig.MovieFile
+}
+
+// Controller of a player
+type PlayerConfig struct {
+	A        Bindings  `toml:"a"`
+	B        Bindings  `toml:"b"`
+	Select   Bindings  `toml:"select"`
+	Start    Bindings  `toml:"start"`
+	Up       Bindings  `toml:"up"`
+	Down     Bindings  `toml:"down"`
+	Left     Bindings  `toml:"left"`
+	Right    Bindings  `toml:"right"`
+	Gamepad  GamepadID `toml:"gamepad"`  // Used by "Gamepad:" bindings
+	Deadzone float64   `toml:"deadzone"` // Analog stick tilt (0.0 ~ 1.0) below which the stick is ignored
+
+	TurboA    Bindings `toml:"turbo_a"`
+	TurboB    Bindings `toml:"turbo_b"`
+	TurboRate float64  `toml:"turbo_rate"` // Presses per second (e.g. 15 or 30)
+}
+
+// Macro file (see joypad.ParseMacro) played on a player's controller by a key
+type MacroConfig struct {
+	Key    Bindings `toml:"key"`
+	File   string   `toml:"file"`
+	Player int      `toml:"player"` // 1 ~ 4 (0 = 1)
+}
+
+// Buttons returns the bindings in joypad bit order (A, B, SELECT, START, UP, DOWN, LEFT, RIGHT).
+func (p PlayerConfig) Buttons() [8]Bindings {
+	return [8]Bindings{p.A, p.B, p.Select, p.Start, p.Up, p.Down, p.Left, p.Right}
+}
+
+// Players returns the controllers of players 1 ~ 4.
+func (c *Config) Players() [4]PlayerConfig {
+	return [4]PlayerConfig{c.Player1, c.Player2, c.Player3, c.Player4}
+}
+
+// The keys are given in joypad bit order and may be omitted.
+// Every player has the same gamepad bindings, on its own gamepad.
+// Turbo A / B are on Y / X.
+func defaultPlayer(gamepad GamepadID, keys ...string) PlayerConfig {
+	buttons := [8]Bindings{
+		{"Gamepad:B"}, {"Gamepad:A"}, {"Gamepad:Back"}, {"Gamepad:Start"},
+		{"Gamepad:Up", "Gamepad:LeftStickUp"}, {"Gamepad:Down", "Gamepad:LeftStickDown"},
+		{"Gamepad:Left", "Gamepad:LeftStickLeft"}, {"Gamepad:Right", "Gamepad:LeftStickRight"},
+	}
+	for i, k := range keys {
+		buttons[i] = append(Bindings{k}, buttons[i]...)
+	}
+	return PlayerConfig{
+		A: buttons[0], B: buttons[1], Select: buttons[2], Start: buttons[3],
+		Up: buttons[4], Down: buttons[5], Left: buttons[6], Right: buttons[7],
+		Gamepad:   gamepad,
+		Deadzone:  0.5,
+		TurboA:    Bindings{"Gamepad:Y"},
+		TurboB:    Bindings{"Gamepad:X"},
+		TurboRate: 15,
+	}
+}
+
+// Bindings are the names of the keys and gamepad buttons bound to an action:
+// ebiten.Key names (e.g. "Z", "ShiftLeft") or "Gamepad:<button>".
+// In config.toml, it is a string or an array of strings.
+type Bindings []string
+
+func (b *Bindings) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*b = Bindings{v}
+	case []any:
+		*b = make(Bindings, 0, len(v))
+		for _, name := range v {
+			s, ok := name.(string)
+			if !ok {
+				return fmt.Errorf("binding %v is not a string", name)
+			}
+			*b = append(*b, s)
+		}
+	default:
+		return fmt.Errorf("bindings must be a string or an array of strings, not %T", v)
+	}
+	return nil
+}
+
+// GamepadID is an ebiten.GamepadID.
+type GamepadID int
+
+func (g *GamepadID) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case int64:
+		*g = GamepadID(v)
+	case map[string]any:
+		// A [playerN.gamepad] table of older versions (see migrateLegacyBindings)
+	default:
+		return fmt.Errorf("gamepad must be a gamepad ID, not %T", v)
+	}
+	return nil
 }
 
-type GamepadConfig struct {
-	IsEnabled bool   `toml:"enabled"`
-	Bind      [8]int `toml:"bind"`
+// Power Pad / Family Trainer mat
+type PowerPadConfig struct {
+	Keys [12]string `toml:"keys"` // ebiten.Key names of buttons 1 ~ 12, "" = none
 }
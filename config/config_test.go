@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func loadString(t *testing.T, s string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+// The config.toml of the repository loads.
+func TestLoadRepositoryConfig(t *testing.T) {
+	if _, err := Load(filepath.Join("..", "config.toml")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := loadString(t, "[video]\nscale = 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Video.IsIntegerScaling {
+		t.Error("integer_scaling is off by default")
+	}
+	if cfg.Video.Overscan.Top != 8 || cfg.Video.Overscan.Bottom != 8 {
+		t.Errorf("overscan = %+v", cfg.Video.Overscan)
+	}
+	cfg, err = loadString(t, "[video]\ninteger_scaling = false\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Video.IsIntegerScaling {
+		t.Error("integer_scaling = false is ignored")
+	}
+}
+
+// The bindings of older versions are migrated.
+func TestLoadLegacyBindings(t *testing.T) {
+	cfg, err := loadString(t, `
+[gamepad]
+enabled = true
+id = 2
+bind = [2, 0, 10, 11, 12, 14, 15, 13]
+
+[player2]
+keys = ["Q", "", "R", "T", "I", "K", "J", "L"]
+
+[player3.gamepad]
+enabled = false
+id = 5
+bind = [2, 0, 10, 11, 12, 14, 15, 13]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  Bindings
+		want Bindings
+	}{
+		{"player1 a", cfg.Player1.A, Bindings{"Z", "Gamepad:Button2"}},
+		{"player1 right", cfg.Player1.Right, Bindings{"ArrowRight", "Gamepad:Button13"}},
+		{"player2 a", cfg.Player2.A, Bindings{"Q", "Gamepad:B"}},
+		{"player2 b", cfg.Player2.B, Bindings{"Gamepad:A"}},
+		{"player2 up", cfg.Player2.Up, Bindings{"I", "Gamepad:Up", "Gamepad:LeftStickUp"}},
+		{"player3 a", cfg.Player3.A, nil},
+		{"player4 a", cfg.Player4.A, Bindings{"Gamepad:B"}},
+	}
+	for _, tt := range tests {
+		if !slices.Equal(tt.got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	for i, want := range []GamepadID{2, 1, 5, 3} {
+		if got := cfg.Players()[i].Gamepad; got != want {
+			t.Errorf("player%d gamepad = %d, want %d", i+1, got, want)
+		}
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	cfg, err := loadString(t, "[player2]\na = \"Q\"\nb = [\"W\", \"Gamepad:A\"]\ngamepad = 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := cfg.Player2
+	if len(p.A) != 1 || p.A[0] != "Q" || len(p.B) != 2 || p.B[1] != "Gamepad:A" || p.Gamepad != 3 {
+		t.Errorf("player2 = %+v", p)
+	}
+	// Unset buttons keep their defaults.
+	if len(p.Start) == 0 || p.Start[0] != "T" {
+		t.Errorf("player2 start = %v", p.Start)
+	}
+}
+
+// The Power Pad keys do not overlap the other default key bindings.
+func TestDefaultPowerPadKeys(t *testing.T) {
+	defaults, err := loadString(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Load(filepath.Join("..", "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, cfg := range map[string]*Config{"defaults": defaults, "config.toml": repo} {
+		h := cfg.Hotkeys
+		all := []Bindings{h.Pause, h.Step, h.Quit, h.Fullscreen, h.RecordMacro, h.PlayMacro,
+			h.RecordMovie, h.RecordMovieFromState, h.PlayMovie}
+		for _, p := range cfg.Players() {
+			buttons := p.Buttons()
+			all = append(all, buttons[:]...)
+			all = append(all, p.TurboA, p.TurboB)
+		}
+		used := map[string]bool{}
+		for _, names := range all {
+			for _, name := range names {
+				used[strings.ToLower(name)] = true
+			}
+		}
+		for i, key := range cfg.PowerPad.Keys {
+			if used[strings.ToLower(key)] {
+				t.Errorf("%s: Power Pad button %d (%s) is bound to another action", name, i+1, key)
+			}
+		}
+	}
+}
@@ -1,386 +1,275 @@
 package apu
 
-/*
 import (
-	"encoding/binary"
 	"math"
-	"time"
+	"nesutaro/internal/region"
 )
 
-const SampleRate float64 = 44100.0
-const CyclesPerSample float64 = 4194304.0 / SampleRate
-const SamplesPerLengthTimerTick float64 = SampleRate / 256.0
-const SamplesPerEnvelopeTick float64 = SampleRate / 64.0
-const SamplesPerSweepTick float64 = SampleRate / 128.0
+// SampleRate is the rate of the samples made by the APU, in Hz.
+const SampleRate = 44100
 
-var b [8]byte // Declared here for optimization.
+// Samples not taken within a second are dropped.
+const maxSamples = SampleRate
 
+// APU is the audio processing unit of the 2A03: 2 pulse channels, a triangle,
+// a noise and a DMC (delta modulation) channel.
+// Its frame counter is on the CPU bus, along with its IRQ: the bus calls
+// QuarterFrame() and HalfFrame() to clock the envelopes, sweeps and lengths.
 type APU struct {
-	AudioStream *AudioStream
-
-	cycles float64
-
-	// For debug
-	debugStrings              []string
-	timeOfDebugStringsCreated time.Time
-
-	// Global control registers
-	nr52 byte
-	nr51 byte
-	nr50 byte
-
-	// Sound channel 1
-	nr10                         byte
-	nr11                         byte
-	nr12                         byte
-	nr13                         byte
-	nr14                         byte
-	ch1Vol                       byte
-	ch1SampleCountForLengthTimer float64
-	ch1SampleCountForEnvelope    float64
-	ch1LengthTimer               int
-	ch1Phase                     float64
-	ch1SampleCountForSweep       float64
-	ch1Period                    uint16
-
-	// Sound channel 2
-	nr21                         byte
-	nr22                         byte
-	nr23                         byte
-	nr24                         byte
-	ch2Vol                       byte
-	ch2SampleCountForLengthTimer float64
-	ch2SampleCountForEnvelope    float64
-	ch2LengthTimer               int
-	ch2Phase                     float64
-	ch2Period                    uint16
-
-	// Sound channel 3
-	waveRAM                      [16]byte
-	nr30                         byte
-	nr31                         byte
-	nr32                         byte
-	nr33                         byte
-	nr34                         byte
-	ch3SampleCountForLengthTimer float64
-	ch3LengthTimer               int
-	ch3Phase                     float64
-	indexWaveRAM                 int
-
-	// Sound channel 4
-	nr41                         byte
-	nr42                         byte
-	nr43                         byte
-	nr44                         byte
-	ch4Vol                       byte
-	ch4SampleCountForLengthTimer float64
-	ch4SampleCountForEnvelope    float64
-	ch4SampleCountForLFSR        float64
-	ch4LengthTimer               int
-	lfsr                         uint16
-
-	distanceThreshold    int
-	samplingAcceleration float64
+	pulses     [2]pulse
+	triangle   triangle
+	noise      noise
+	dmc        dmc
+	isOddCycle bool // Pulse timers run every other CPU cycle.
+
+	timing region.Timing
+
+	// The DMC reads its samples through the DMA of the CPU bus
+	// and raises its IRQ there.
+	requestDMA func(addr uint16, done func(val byte))
+	setIRQ     func()
+
+	// Output, averaged over the CPU cycles of each sample
+	sampleSum    float64
+	sampleCycles int
+	sampleClock  float64 // SampleRate per CPU cycle, until it reaches the CPU clock
+	hpIn, hpOut  float64 // High-pass filter
+	samples      []float32
 }
 
-func NewAPU() *APU {
-	bufferMilliSecond := float64(120)
-	bufferSize := int(SampleRate * 8 * bufferMilliSecond / 1000)
+func NewAPU(requestDMA func(addr uint16, done func(val byte)), setIRQ func()) *APU {
 	a := &APU{
-		AudioStream:          NewAudioStream(bufferSize),
-		lfsr:                 0x7FFF,
-		samplingAcceleration: 1.0,
+		timing:     region.NTSC.Timing(),
+		requestDMA: requestDMA,
+		setIRQ:     setIRQ,
+		samples:    make([]float32, 0, maxSamples),
 	}
-
-	a.distanceThreshold = int(float64(len(a.AudioStream.buffer)) * 0.40)
+	a.noise.Shift = 1
+	a.dmc.BitsLeft = 8
+	a.dmc.IsSilent = true
 	return a
 }
 
-// The Step adjusts the sampling interval depending on the current buffer size.
-func (a *APU) Step(cpuCycles int) {
-	targetAcceleration := 1.0
-	w := a.AudioStream.w
-	r := a.AudioStream.r
-	t := a.distanceThreshold
-	if w-r < -t {
-		targetAcceleration = 1.5
-	} else if w-r > t {
-		targetAcceleration = 0.5
-	}
-	a.samplingAcceleration += (targetAcceleration - a.samplingAcceleration) * 0.01
-	a.cycles += float64(cpuCycles) * a.samplingAcceleration
-	for a.cycles >= CyclesPerSample {
-		a.cycles -= CyclesPerSample
-		b := a.generateSample()
-		a.AudioStream.write(b)
-	}
-}
-
-func (a *APU) ReadWaveRAM(addr uint16) byte {
-	return a.waveRAM[addr]
-}
-
-func (a *APU) WriteWaveRAM(addr uint16, val byte) {
-	a.waveRAM[addr] = val
+// The region sets the noise and DMC periods and the CPU clock the samples are made from.
+func (a *APU) SetRegion(r region.Region) {
+	a.timing = r.Timing()
 }
 
-func (a *APU) GetAPUInfo() []string {
-	if time.Since(a.timeOfDebugStringsCreated).Milliseconds() >= 500 {
-		a.debugStrings = []string{}
-		//a.debugStrings = append(a.debugStrings, "BUF STATUS")
-		a.timeOfDebugStringsCreated = time.Now()
+// Step advances the APU by one CPU cycle.
+func (a *APU) Step() {
+	if a.isOddCycle {
+		a.pulses[0].clockTimer()
+		a.pulses[1].clockTimer()
 	}
-	return a.debugStrings
+	a.isOddCycle = !a.isOddCycle
+	a.triangle.clockTimer()
+	a.noise.clockTimer(a.timing.NoisePeriods)
+	a.stepDMC()
+	a.stepOutput()
 }
 
-func (a *APU) generateSample() [8]byte {
-	sr1, sl1 := a.generateSquareChannel(1)
-	sr2, sl2 := a.generateSquareChannel(2)
-	sr3, sl3 := a.generateWaveChannel()
-	sr4, sl4 := a.generateNoiseChannel()
-	// sr1 = 0
-	// sl1 = 0
-	// sr2 = 0
-	// sl2 = 0
-	// sr3 = 0
-	// sl3 = 0
-	// sr4 = 0
-	// sl4 = 0
-	sampleR := float32((sr1 + sr2 + sr3 + sr4) / 4.0)
-	sampleL := float32((sl1 + sl2 + sl3 + sl4) / 4.0)
-	binary.LittleEndian.PutUint32(b[0:4], math.Float32bits(sampleL))
-	binary.LittleEndian.PutUint32(b[4:8], math.Float32bits(sampleR))
-	return b
+// QuarterFrame clocks the envelopes and the linear counter of the triangle.
+func (a *APU) QuarterFrame() {
+	a.pulses[0].Env.clock()
+	a.pulses[1].Env.clock()
+	a.noise.Env.clock()
+	a.triangle.clockLinear()
 }
 
-// Channel 1 & 2.
-func (a *APU) generateSquareChannel(ch byte) (float64, float64) {
-	var nrX1 byte
-	var nrX2 byte
-	//var nrX3 byte
-	var nrX4 byte
-	var chXPhase *float64
-	var chXSampleCountForLengthTimer *float64
-	var chXLengthTimer *int
-	var chXSampleCountForEnvelope *float64
-	var chXVol *byte
-	var chXPeriod *uint16
-	switch ch {
-	case 1:
-		nrX1 = a.nr11
-		nrX2 = a.nr12
-		//nrX3 = a.nr13
-		nrX4 = a.nr14
-		chXPhase = &a.ch1Phase
-		chXSampleCountForLengthTimer = &a.ch1SampleCountForLengthTimer
-		chXLengthTimer = &a.ch1LengthTimer
-		chXSampleCountForEnvelope = &a.ch1SampleCountForEnvelope
-		chXVol = &a.ch1Vol
-		chXPeriod = &a.ch1Period
-	case 2:
-		nrX1 = a.nr21
-		nrX2 = a.nr22
-		//nrX3 = a.nr23
-		nrX4 = a.nr24
-		chXPhase = &a.ch2Phase
-		chXSampleCountForLengthTimer = &a.ch2SampleCountForLengthTimer
-		chXLengthTimer = &a.ch2LengthTimer
-		chXSampleCountForEnvelope = &a.ch2SampleCountForEnvelope
-		chXVol = &a.ch2Vol
-		chXPeriod = &a.ch2Period
-	default:
-		panic("")
-	}
+// HalfFrame clocks the length counters and the sweeps.
+func (a *APU) HalfFrame() {
+	a.pulses[0].clockLength()
+	a.pulses[1].clockLength()
+	a.triangle.clockLength()
+	a.noise.clockLength()
+	a.pulses[0].clockSweep(1)
+	a.pulses[1].clockSweep(0)
+}
 
-	// Sweep function is only available on Channel 1.
-	if ch == 1 {
-		pace := a.nr10 & 0x70 >> 4
-		if pace != 0 {
-			sweepItaration := SamplesPerSweepTick * float64(pace)
-			a.ch1SampleCountForSweep++
-			for a.ch1SampleCountForSweep >= sweepItaration {
-				a.ch1SampleCountForSweep -= sweepItaration
-				individualStep := int(a.nr10 & 0x07)
-				delta := int(*chXPeriod) >> individualStep
-				direction := a.nr10 & (1 << 3) >> 3
-				if direction == 1 {
-					delta = -delta
-				}
-				tmpPeriod := int(*chXPeriod) + delta
-				if tmpPeriod < 0 || tmpPeriod > 0x7FF {
-					a.nr52 = a.nr52 & 0xFE
-				} else {
-					*chXPeriod = uint16(tmpPeriod)
-				}
+// ========================================= Registers =============================================
+
+// Write writes a channel register ($4000 ~ $4013).
+func (a *APU) Write(addr uint16, val byte) {
+	switch {
+	case addr <= 0x4007:
+		p := &a.pulses[addr>>2&1]
+		switch addr & 3 {
+		case 0:
+			p.Duty = val >> 6
+			p.Env.write(val)
+		case 1:
+			p.IsSweepEnabled = val&0x80 != 0
+			p.SweepPeriod = val >> 4 & 7
+			p.IsSweepNegated = val&0x08 != 0
+			p.SweepShift = val & 7
+			p.IsSweepReloaded = true
+		case 2:
+			p.Period = p.Period&0x700 | int(val)
+		case 3:
+			p.Period = p.Period&0xFF | int(val&7)<<8
+			if p.IsEnabled {
+				p.Length = lengthTable[val>>3]
 			}
+			p.Step = 0
+			p.Env.IsStarted = true
+		}
+	case addr == 0x4008:
+		a.triangle.IsControlled = val&0x80 != 0
+		a.triangle.LinearReload = val & 0x7F
+	case addr == 0x400A:
+		a.triangle.Period = a.triangle.Period&0x700 | int(val)
+	case addr == 0x400B:
+		a.triangle.Period = a.triangle.Period&0xFF | int(val&7)<<8
+		if a.triangle.IsEnabled {
+			a.triangle.Length = lengthTable[val>>3]
 		}
+		a.triangle.IsLinearReloaded = true
+	case addr == 0x400C:
+		a.noise.Env.write(val)
+	case addr == 0x400E:
+		a.noise.IsShortMode = val&0x80 != 0
+		a.noise.PeriodIndex = val & 0x0F
+	case addr == 0x400F:
+		if a.noise.IsEnabled {
+			a.noise.Length = lengthTable[val>>3]
+		}
+		a.noise.Env.IsStarted = true
+	case addr == 0x4010:
+		a.dmc.IsIRQEnabled = val&0x80 != 0
+		a.dmc.IsLooped = val&0x40 != 0
+		a.dmc.Rate = val & 0x0F
+	case addr == 0x4011:
+		a.dmc.Output = val & 0x7F
+	case addr == 0x4012:
+		a.dmc.SampleAddr = 0xC000 | uint16(val)<<6
+	case addr == 0x4013:
+		a.dmc.SampleLength = int(val)<<4 + 1
 	}
+}
 
-	freqency := 131072.0 / (2048.0 - float64(*chXPeriod))
-	*chXPhase += freqency / float64(SampleRate)
-	for *chXPhase >= 1.0 {
-		*chXPhase -= 1.0
+// WriteStatus enables the channels ($4015 bits 0 ~ 4).
+// A disabled channel is silenced at once: its length counter is cleared.
+// Enabling the DMC starts its sample over unless it is still playing.
+func (a *APU) WriteStatus(val byte) {
+	a.pulses[0].setEnabled(val&0x01 != 0)
+	a.pulses[1].setEnabled(val&0x02 != 0)
+	a.triangle.IsEnabled = val&0x04 != 0
+	if !a.triangle.IsEnabled {
+		a.triangle.Length = 0
 	}
-	var dutyRatio float64
-	waveDuty := nrX1 >> 6
-	switch waveDuty {
-	case 0:
-		dutyRatio = 0.125
-	case 1:
-		dutyRatio = 0.25
-	case 2:
-		dutyRatio = 0.5
-	case 3:
-		dutyRatio = 0.75
+	a.noise.IsEnabled = val&0x08 != 0
+	if !a.noise.IsEnabled {
+		a.noise.Length = 0
 	}
-
-	a.execLengthTimer(ch, nrX4, chXLengthTimer, chXSampleCountForLengthTimer)
-	a.execEnvelope(chXVol, nrX2, chXSampleCountForEnvelope)
-	volumeR, volumeL := a.execMixing(ch, float64(*chXVol)/15.0)
-
-	var sampleR, sampleL float64
-	if *chXPhase < dutyRatio {
-		sampleR = +volumeR
-		sampleL = +volumeL
-	} else {
-		sampleR = -volumeR
-		sampleL = -volumeL
+	if val&0x10 == 0 {
+		a.dmc.BytesLeft = 0
+	} else if a.dmc.BytesLeft == 0 {
+		a.dmc.restart()
 	}
-	return sampleR, sampleL
 }
 
-// Channel 3.
-func (a *APU) generateWaveChannel() (float64, float64) {
-	period := uint16(a.nr34&0x07)<<8 | uint16(a.nr33)
-	freqency := (65536.0 / (2048.0 - float64(period))) * 32
-
-	a.ch3Phase += freqency / float64(SampleRate)
-	for a.ch3Phase >= 1.0 {
-		a.ch3Phase -= 1.0
-		a.indexWaveRAM = (a.indexWaveRAM + 1) % 32
+// Status returns $4015 bits 0 ~ 4: whether each channel is still playing.
+// The IRQ flags (bits 6, 7) are on the CPU bus.
+func (a *APU) Status() byte {
+	var val byte
+	if a.pulses[0].Length > 0 {
+		val |= 0x01
 	}
-
-	// ram[0]hi, ram[0]lo, ram[1]hi...
-	wave := a.waveRAM[a.indexWaveRAM/2]
-	if a.indexWaveRAM%2 == 0 {
-		wave >>= 4
-	} else {
-		wave &= 0x0F
+	if a.pulses[1].Length > 0 {
+		val |= 0x02
 	}
-
-	outputLevel := a.nr32 & 0x60 >> 5
-	switch outputLevel {
-	case 0:
-		wave = 0
-	case 2:
-		wave >>= 1
-	case 3:
-		wave >>= 2
+	if a.triangle.Length > 0 {
+		val |= 0x04
 	}
+	if a.noise.Length > 0 {
+		val |= 0x08
+	}
+	if a.dmc.BytesLeft > 0 {
+		val |= 0x10
+	}
+	return val
+}
 
-	a.execLengthTimer(3, a.nr34, &a.ch3LengthTimer, &a.ch3SampleCountForLengthTimer)
-	volumeR, volumeL := a.execMixing(3, 1.0)
+// ============================================ DMC ================================================
 
-	isDACOn := a.nr30&0x80 != 0
-	if !isDACOn {
-		volumeR = 0
-		volumeL = 0
+// The DMC fetches the next byte of its sample as soon as its buffer is empty.
+// The CPU bus reads it with a DMA and hands it to fillDMC().
+func (a *APU) stepDMC() {
+	d := &a.dmc
+	if !d.IsBufferFull && d.BytesLeft > 0 && !d.IsFetching {
+		d.IsFetching = true
+		a.requestDMA(d.Addr, a.fillDMC)
 	}
-
-	var sampleR, sampleL float64
-	sampleR = (float64(wave)/7.5 - 1.0) * volumeR // ram[x]hi/lo = 0~15 to -1~+1.
-	sampleL = (float64(wave)/7.5 - 1.0) * volumeL // ram[x]hi/lo = 0~15 to -1~+1.
-	return sampleR, sampleL
+	if d.Timer > 0 {
+		d.Timer--
+		return
+	}
+	d.Timer = a.timing.DMCPeriods[d.Rate] - 1
+	d.clockOutput()
 }
 
-// Channel 4
-func (a *APU) generateNoiseChannel() (float64, float64) {
-	clockShift := float64(a.nr43 >> 4)
-	clockDivider := float64(a.nr43 & 0x07)
-	if clockDivider == 0 {
-		clockDivider = 0.5
+func (a *APU) fillDMC(val byte) {
+	d := &a.dmc
+	d.IsFetching = false
+	d.Buffer, d.IsBufferFull = val, true
+	d.Addr++
+	if d.Addr == 0 {
+		d.Addr = 0x8000
 	}
-	lfsrWidth := a.nr43 & (1 << 3) >> 3 // 0:15bit   1:7bit
-	a.ch4SampleCountForLFSR++
-	// Set a min value for when LFSR clock > Sample rate.
-	samplesPerLFSRClock := max(1.0, SampleRate/(262144.0/(clockDivider*math.Pow(2, clockShift))))
-	for a.ch4SampleCountForLFSR >= samplesPerLFSRClock {
-		a.ch4SampleCountForLFSR -= samplesPerLFSRClock
-		xor := a.lfsr&1 ^ a.lfsr&2>>1
-		a.lfsr = xor<<15 | a.lfsr&^(1<<15)
-		if lfsrWidth == 1 {
-			a.lfsr = xor<<7 | a.lfsr&^(1<<7)
+	d.BytesLeft--
+	if d.BytesLeft == 0 {
+		if d.IsLooped {
+			d.restart()
+		} else if d.IsIRQEnabled {
+			a.setIRQ()
 		}
-		a.lfsr >>= 1
 	}
+}
 
-	a.execLengthTimer(4, a.nr44, &a.ch4LengthTimer, &a.ch4SampleCountForLengthTimer)
-	a.execEnvelope(&a.ch4Vol, a.nr42, &a.ch4SampleCountForEnvelope)
-	volumeR, volumeL := a.execMixing(4, float64(a.ch4Vol)/15.0)
+// ========================================== Output ===============================================
 
-	var sampleR, sampleL float64
-	if a.lfsr&1 == 0 {
-		sampleR = +volumeR
-		sampleL = +volumeL
-	} else {
-		sampleR = -volumeR
-		sampleL = -volumeL
+// The channels are mixed with the lookup tables of the nonlinear 2A03 DAC.
+var pulseTable, tndTable = func() (p [31]float64, tnd [203]float64) {
+	for i := 1; i < len(p); i++ {
+		p[i] = 95.52 / (8128/float64(i) + 100)
 	}
-	return sampleR, sampleL
-}
-
-func (a *APU) execMixing(ch byte, chVolume float64) (float64, float64) {
-	isAudioOn := a.nr52&(1<<7) != 0
-	isChannelOn := a.nr52&(1<<(ch-1)) != 0
-	if !isAudioOn || !isChannelOn {
-		return 0, 0
+	for i := 1; i < len(tnd); i++ {
+		tnd[i] = 163.67 / (24329/float64(i) + 100)
 	}
-	panR := float64((a.nr51 & (1 << (ch - 1))) >> (ch - 1)) // 0 or 1
-	panL := float64((a.nr51 & (1 << (ch + 3))) >> (ch + 3)) // 0 or 1
-	masterR := float64(a.nr50&0x07) / 7.0
-	masterL := float64(a.nr50&0x70>>4) / 7.0
-	volumeR := panR * masterR * chVolume
-	volumeL := panL * masterL * chVolume
-	return volumeR, volumeL
-}
-
-func (a *APU) execLengthTimer(ch, controlRegister byte, lengthTimer *int, sampleCounter *float64) {
-	maxLength := 64
-	if ch == 3 {
-		maxLength = 256
+	return p, tnd
+}()
+
+// The high-pass filter of the NES removes the DC offset of the DAC (90 Hz).
+var hpAlpha = func() float64 {
+	rc := 1 / (2 * math.Pi * 90)
+	return rc / (rc + 1.0/SampleRate)
+}()
+
+func (a *APU) stepOutput() {
+	p := a.pulses[0].output() + a.pulses[1].output()
+	tnd := 3*int(a.triangle.output()) + 2*int(a.noise.output()) + int(a.dmc.Output)
+	a.sampleSum += pulseTable[p] + tndTable[tnd]
+	a.sampleCycles++
+
+	a.sampleClock += SampleRate
+	if a.sampleClock < a.timing.CPUClock {
+		return
 	}
-	isChannelOn := a.nr52&(1<<(ch-1)) != 0
-	isLengthEnabled := controlRegister&(1<<6) != 0
-	if isChannelOn && isLengthEnabled {
-		(*sampleCounter)++
-		for *sampleCounter >= SamplesPerLengthTimerTick {
-			*sampleCounter -= SamplesPerLengthTimerTick
-			if *lengthTimer >= maxLength {
-				a.nr52 &^= 1 << (ch - 1) // Disable chX.
-			} else {
-				(*lengthTimer)++
-			}
-		}
+	a.sampleClock -= a.timing.CPUClock
+	in := a.sampleSum / float64(a.sampleCycles)
+	a.sampleSum, a.sampleCycles = 0, 0
+	a.hpOut = hpAlpha * (a.hpOut + in - a.hpIn)
+	a.hpIn = in
+	if len(a.samples) >= maxSamples {
+		a.samples = a.samples[:0]
 	}
+	a.samples = append(a.samples, float32(a.hpOut))
 }
 
-func (a *APU) execEnvelope(chVolume *byte, envelopeRegister byte, sampleCounter *float64) {
-	envelopePeriod := envelopeRegister & 0x07
-	if envelopePeriod != 0 {
-		(*sampleCounter)++
-		for *sampleCounter >= SamplesPerEnvelopeTick*float64(envelopePeriod) {
-			*sampleCounter -= SamplesPerEnvelopeTick * float64(envelopePeriod)
-			isEnvelopeDirectionUp := envelopeRegister&(1<<3) != 0
-			if isEnvelopeDirectionUp {
-				if *chVolume < 15 {
-					(*chVolume)++
-				}
-			} else {
-				if *chVolume > 0 {
-					(*chVolume)--
-				}
-			}
-		}
-	}
+// TakeSamples appends the samples made since the previous call to dst:
+// mono, SampleRate Hz, about -1 ~ 1.
+func (a *APU) TakeSamples(dst []float32) []float32 {
+	dst = append(dst, a.samples...)
+	a.samples = a.samples[:0]
+	return dst
 }
-*/
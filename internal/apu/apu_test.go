@@ -0,0 +1,165 @@
+package apu
+
+import (
+	"nesutaro/internal/region"
+	"testing"
+)
+
+type dmaRequest struct {
+	addr uint16
+	done func(val byte)
+}
+
+func newTestAPU() (*APU, *[]dmaRequest, *int) {
+	var requests []dmaRequest
+	irqs := 0
+	a := NewAPU(func(addr uint16, done func(val byte)) {
+		requests = append(requests, dmaRequest{addr, done})
+	}, func() { irqs++ })
+	return a, &requests, &irqs
+}
+
+func TestLengthCounter(t *testing.T) {
+	a, _, _ := newTestAPU()
+	a.Write(0x4003, 0x08) // Length index 1 while disabled: ignored
+	if s := a.Status(); s != 0 {
+		t.Fatalf("status = %02X with the channels disabled", s)
+	}
+	a.WriteStatus(0x0F)
+	a.Write(0x4003, 0x18) // Pulse 1: 2 half frames
+	a.Write(0x4007, 0x08) // Pulse 2: 254
+	a.Write(0x4008, 0x80) // Triangle halted
+	a.Write(0x400B, 0x18) // Triangle: 2
+	a.Write(0x400F, 0x18) // Noise: 2
+	if s := a.Status(); s != 0x0F {
+		t.Fatalf("status = %02X, want 0F", s)
+	}
+	a.HalfFrame()
+	a.HalfFrame()
+	if s := a.Status(); s != 0x06 {
+		t.Errorf("status after 2 half frames = %02X, want 06", s)
+	}
+	a.WriteStatus(0x04)
+	if s := a.Status(); s != 0x04 {
+		t.Errorf("status after disabling pulse 2 = %02X, want 04", s)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	a, _, _ := newTestAPU()
+	a.Write(0x400C, 0x01) // Decay, divider period 1
+	a.Write(0x400F, 0x00)
+	a.QuarterFrame() // Start: 15
+	// The volume decays every 2 clocks.
+	want := []byte{15, 15, 14, 14, 13}
+	for i, v := range want {
+		if got := a.noise.Env.output(); got != v {
+			t.Fatalf("volume after %d quarter frames = %d, want %d", i+1, got, v)
+		}
+		a.QuarterFrame()
+	}
+	a.Write(0x400C, 0x17) // Constant volume 7
+	if got := a.noise.Env.output(); got != 7 {
+		t.Errorf("constant volume = %d, want 7", got)
+	}
+}
+
+func TestSweepMutesOverflow(t *testing.T) {
+	a, _, _ := newTestAPU()
+	a.Write(0x4002, 0xFF)
+	a.Write(0x4003, 0x07) // Period $7FF
+	a.Write(0x4001, 0x01) // Sweep disabled, shift 1: the target overflows.
+	if !a.pulses[0].isMuted() {
+		t.Error("period $7FF with shift 1 is not muted")
+	}
+	a.Write(0x4001, 0x09) // Negated
+	if a.pulses[0].isMuted() {
+		t.Error("negated sweep is muted")
+	}
+	a.Write(0x4001, 0x89) // Enabled, period 0, negated, shift 1
+	a.HalfFrame()
+	if p := a.pulses[0].Period; p != 0x7FF-0x3FF-1 {
+		t.Errorf("pulse 1 period after a sweep = %03X, want %03X", p, 0x7FF-0x3FF-1)
+	}
+}
+
+func TestDMC(t *testing.T) {
+	a, requests, irqs := newTestAPU()
+	a.Write(0x4010, 0x8F) // IRQ, fastest rate
+	a.Write(0x4012, 0x01) // $C040
+	a.Write(0x4013, 0x00) // 1 byte
+	a.WriteStatus(0x10)
+	if s := a.Status(); s != 0x10 {
+		t.Fatalf("status = %02X, want 10", s)
+	}
+	a.Step()
+	a.Step()
+	if len(*requests) != 1 || (*requests)[0].addr != 0xC040 {
+		t.Fatalf("DMA requests = %v, want one at $C040", *requests)
+	}
+	(*requests)[0].done(0xFF)
+	if s := a.Status(); s != 0 || *irqs != 1 {
+		t.Errorf("after the last byte: status = %02X, %d IRQs", s, *irqs)
+	}
+	// The 8 bits of the byte raise the level by 2 each.
+	a.Write(0x4011, 0x10)
+	for i := 0; i < 16*54; i++ {
+		a.Step()
+	}
+	if a.dmc.Output != 0x10+16 {
+		t.Errorf("output = %d, want %d", a.dmc.Output, 0x10+16)
+	}
+
+	a.Write(0x4010, 0x4F) // Loop
+	a.WriteStatus(0x10)
+	a.Step()
+	(*requests)[1].done(0x00)
+	if s := a.Status(); s != 0x10 || *irqs != 1 {
+		t.Errorf("looped sample: status = %02X, %d IRQs", s, *irqs)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	a, _, _ := newTestAPU()
+	a.SetRegion(region.NTSC)
+	a.WriteStatus(0x01)
+	a.Write(0x4000, 0xBF) // 50%, constant volume 15
+	a.Write(0x4002, 0xFD) // About 440 Hz
+	a.Write(0x4003, 0x00)
+	for i := 0; i < int(region.NTSC.Timing().CPUClock)/60; i++ {
+		a.Step()
+	}
+	s := a.TakeSamples(nil)
+	if len(s) != SampleRate/60 && len(s) != SampleRate/60-1 {
+		t.Fatalf("%d samples in a frame, want %d", len(s), SampleRate/60)
+	}
+	lo, hi := s[0], s[0]
+	for _, v := range s {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if hi-lo < 0.1 || lo < -1 || hi > 1 {
+		t.Errorf("pulse samples range from %f to %f", lo, hi)
+	}
+	if s := a.TakeSamples(nil); len(s) != 0 {
+		t.Errorf("%d samples taken twice", len(s))
+	}
+}
+
+func TestLoadStateRejectsBadIndices(t *testing.T) {
+	a, _, _ := newTestAPU()
+	for name, f := range map[string]func(s *State){
+		"duty":       func(s *State) { s.Pulses[1].Duty = 4 },
+		"noise rate": func(s *State) { s.Noise.PeriodIndex = 16 },
+		"dmc rate":   func(s *State) { s.DMC.Rate = 16 },
+		"triangle":   func(s *State) { s.Triangle.Step = 32 },
+	} {
+		s := a.SaveState()
+		f(&s)
+		if err := a.LoadState(s); err == nil {
+			t.Errorf("LoadState(%s): no error", name)
+		}
+	}
+	if err := a.LoadState(a.SaveState()); err != nil {
+		t.Error(err)
+	}
+}
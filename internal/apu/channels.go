@@ -0,0 +1,295 @@
+package apu
+
+// The channel fields are exported for save states (gob).
+
+var lengthTable = [32]int{
+	10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30,
+}
+
+// ========================================= Envelope ==============================================
+
+// An envelope makes the volume of a pulse or noise channel decay from 15 to 0,
+// or gives a constant volume.
+// IsLooped also halts the length counter of the channel.
+type envelope struct {
+	IsStarted  bool
+	IsLooped   bool
+	IsConstant bool
+	Volume     byte // Constant volume, or divider period
+	Divider    byte
+	Decay      byte
+}
+
+// write sets the envelope from bits 0 ~ 5 of $4000, $4004 or $400C.
+func (e *envelope) write(val byte) {
+	e.IsLooped = val&0x20 != 0
+	e.IsConstant = val&0x10 != 0
+	e.Volume = val & 0x0F
+}
+
+func (e *envelope) clock() {
+	if e.IsStarted {
+		e.IsStarted = false
+		e.Decay = 15
+		e.Divider = e.Volume
+		return
+	}
+	if e.Divider > 0 {
+		e.Divider--
+		return
+	}
+	e.Divider = e.Volume
+	if e.Decay > 0 {
+		e.Decay--
+	} else if e.IsLooped {
+		e.Decay = 15
+	}
+}
+
+func (e *envelope) output() byte {
+	if e.IsConstant {
+		return e.Volume
+	}
+	return e.Decay
+}
+
+// =========================================== Pulse ===============================================
+
+var dutyTable = [4][8]byte{
+	{0, 1, 0, 0, 0, 0, 0, 0}, // 12.5%
+	{0, 1, 1, 0, 0, 0, 0, 0}, // 25%
+	{0, 1, 1, 1, 1, 0, 0, 0}, // 50%
+	{1, 0, 0, 1, 1, 1, 1, 1}, // 25% negated
+}
+
+type pulse struct {
+	IsEnabled bool
+	Duty      byte
+	Env       envelope
+	Length    int
+
+	IsSweepEnabled  bool
+	IsSweepNegated  bool
+	IsSweepReloaded bool
+	SweepPeriod     byte
+	SweepShift      byte
+	SweepDivider    byte
+
+	Period int // 11 bits, in APU cycles (2 CPU cycles)
+	Timer  int
+	Step   byte
+}
+
+func (p *pulse) setEnabled(isEnabled bool) {
+	p.IsEnabled = isEnabled
+	if !isEnabled {
+		p.Length = 0
+	}
+}
+
+func (p *pulse) clockTimer() {
+	if p.Timer > 0 {
+		p.Timer--
+		return
+	}
+	p.Timer = p.Period
+	p.Step = (p.Step + 1) & 7
+}
+
+func (p *pulse) clockLength() {
+	if !p.Env.IsLooped && p.Length > 0 {
+		p.Length--
+	}
+}
+
+// The sweep unit bends the period up or down. Pulse 1 negates the change with
+// one's complement, so it subtracts one more than pulse 2 (extra = 1).
+func (p *pulse) sweepTarget(extra int) int {
+	change := p.Period >> p.SweepShift
+	if p.IsSweepNegated {
+		return p.Period - change - extra
+	}
+	return p.Period + change
+}
+
+func (p *pulse) clockSweep(extra int) {
+	if p.SweepDivider == 0 && p.IsSweepEnabled && p.SweepShift > 0 && !p.isMuted() {
+		p.Period = max(p.sweepTarget(extra), 0)
+	}
+	if p.SweepDivider == 0 || p.IsSweepReloaded {
+		p.SweepDivider = p.SweepPeriod
+		p.IsSweepReloaded = false
+	} else {
+		p.SweepDivider--
+	}
+}
+
+// The sweep unit mutes the channel even when it is disabled.
+// Only an increasing target can overflow.
+func (p *pulse) isMuted() bool {
+	return p.Period < 8 || !p.IsSweepNegated && p.sweepTarget(0) > 0x7FF
+}
+
+func (p *pulse) output() int {
+	if p.Length == 0 || dutyTable[p.Duty][p.Step] == 0 || p.isMuted() {
+		return 0
+	}
+	return int(p.Env.output())
+}
+
+// ========================================== Triangle =============================================
+
+var triangleTable = [32]byte{
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0,
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+}
+
+// The triangle has no volume control. A linear counter stops it with a finer
+// resolution than the length counter.
+// IsControlled halts the length counter and keeps reloading the linear counter.
+type triangle struct {
+	IsEnabled        bool
+	IsControlled     bool
+	IsLinearReloaded bool
+	LinearReload     byte
+	Linear           byte
+	Length           int
+
+	Period int // 11 bits, in CPU cycles
+	Timer  int
+	Step   byte
+}
+
+func (t *triangle) clockTimer() {
+	if t.Timer > 0 {
+		t.Timer--
+		return
+	}
+	t.Timer = t.Period
+	if t.Length > 0 && t.Linear > 0 {
+		t.Step = (t.Step + 1) & 31
+	}
+}
+
+func (t *triangle) clockLinear() {
+	if t.IsLinearReloaded {
+		t.Linear = t.LinearReload
+	} else if t.Linear > 0 {
+		t.Linear--
+	}
+	if !t.IsControlled {
+		t.IsLinearReloaded = false
+	}
+}
+
+func (t *triangle) clockLength() {
+	if !t.IsControlled && t.Length > 0 {
+		t.Length--
+	}
+}
+
+// The triangle stops where it is when silenced, so the output stays.
+// Ultrasonic periods (< 2) are muted like most games expect: they use them
+// to silence the channel, which pops on hardware.
+func (t *triangle) output() byte {
+	if t.Period < 2 {
+		return 0
+	}
+	return triangleTable[t.Step]
+}
+
+// =========================================== Noise ===============================================
+
+type noise struct {
+	IsEnabled   bool
+	Env         envelope
+	Length      int
+	IsShortMode bool // Feedback from bit 6 instead of bit 1: a 93-step sequence
+	PeriodIndex byte
+	Timer       int
+	Shift       uint16 // 15-bit LFSR
+}
+
+// periods are the region.Timing NoisePeriods, in CPU cycles.
+func (n *noise) clockTimer(periods [16]int) {
+	if n.Timer > 0 {
+		n.Timer--
+		return
+	}
+	n.Timer = periods[n.PeriodIndex] - 1
+	tap := 1
+	if n.IsShortMode {
+		tap = 6
+	}
+	feedback := (n.Shift ^ n.Shift>>tap) & 1
+	n.Shift = n.Shift>>1 | feedback<<14
+}
+
+func (n *noise) clockLength() {
+	if !n.Env.IsLooped && n.Length > 0 {
+		n.Length--
+	}
+}
+
+func (n *noise) output() byte {
+	if n.Length == 0 || n.Shift&1 == 1 {
+		return 0
+	}
+	return n.Env.output()
+}
+
+// ============================================ DMC ================================================
+
+// The DMC plays 1-bit delta samples read from $8000 ~ $FFFF:
+// each bit moves the 7-bit output level up or down by 2.
+type dmc struct {
+	IsIRQEnabled bool
+	IsLooped     bool
+	Rate         byte   // Index of region.Timing DMCPeriods
+	SampleAddr   uint16 // $C000 + $4012 * 64
+	SampleLength int    // $4013 * 16 + 1 bytes
+	Timer        int
+
+	// Memory reader
+	Addr         uint16
+	BytesLeft    int
+	Buffer       byte
+	IsBufferFull bool
+	IsFetching   bool // A DMA is pending for the buffer.
+
+	// Output unit
+	Output   byte
+	Shift    byte
+	BitsLeft int
+	IsSilent bool
+}
+
+func (d *dmc) restart() {
+	d.Addr = d.SampleAddr
+	d.BytesLeft = d.SampleLength
+}
+
+// When the 8 bits of a byte are out, the next one comes from the buffer.
+// If the buffer is empty, the output unit stays silent for 8 bits.
+func (d *dmc) clockOutput() {
+	if !d.IsSilent {
+		if d.Shift&1 == 1 {
+			if d.Output <= 125 {
+				d.Output += 2
+			}
+		} else if d.Output >= 2 {
+			d.Output -= 2
+		}
+	}
+	d.Shift >>= 1
+	d.BitsLeft--
+	if d.BitsLeft > 0 {
+		return
+	}
+	d.BitsLeft = 8
+	d.IsSilent = !d.IsBufferFull
+	if d.IsBufferFull {
+		d.Shift, d.IsBufferFull = d.Buffer, false
+	}
+}
@@ -0,0 +1,46 @@
+package apu
+
+import "fmt"
+
+// State is a serializable snapshot of the APU channels, used for save states.
+// The samples not taken yet are not part of it.
+type State struct {
+	Pulses     [2]pulse
+	Triangle   triangle
+	Noise      noise
+	DMC        dmc
+	IsOddCycle bool
+}
+
+func (a *APU) SaveState() State {
+	return State{
+		Pulses:     a.pulses,
+		Triangle:   a.triangle,
+		Noise:      a.noise,
+		DMC:        a.dmc,
+		IsOddCycle: a.isOddCycle,
+	}
+}
+
+// LoadState fails if s holds a table index out of range.
+func (a *APU) LoadState(s State) error {
+	for i, p := range s.Pulses {
+		if p.Duty > 3 || p.Step > 7 {
+			return fmt.Errorf("apu: save state has pulse %d at duty %d step %d", i+1, p.Duty, p.Step)
+		}
+	}
+	switch {
+	case s.Triangle.Step > 31:
+		return fmt.Errorf("apu: save state has triangle step %d", s.Triangle.Step)
+	case s.Noise.PeriodIndex > 15:
+		return fmt.Errorf("apu: save state has noise period %d", s.Noise.PeriodIndex)
+	case s.DMC.Rate > 15 || s.DMC.Output > 127:
+		return fmt.Errorf("apu: save state has DMC rate %d level %d", s.DMC.Rate, s.DMC.Output)
+	}
+	a.pulses = s.Pulses
+	a.triangle = s.Triangle
+	a.noise = s.Noise
+	a.dmc = s.DMC
+	a.isOddCycle = s.IsOddCycle
+	return nil
+}
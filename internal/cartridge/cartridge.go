@@ -1,6 +1,7 @@
 package cartridge
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -36,6 +37,33 @@ type Mapper interface {
 	ReadCHRROM(addr uint16) byte
 	WriteCHRROM(addr uint16, val byte)
 	GetSaveData() []byte
+	SaveState() MapperState
+	// LoadState fails if the registers do not fit the mapper.
+	LoadState(s MapperState) error
+}
+
+// MapperState is a serializable snapshot of a mapper's registers,
+// used for save states. Each mapper decides the layout of Regs.
+type MapperState struct {
+	Regs []int
+}
+
+// CheckROM reports whether rom is an iNES image this emulator can load.
+func CheckROM(rom []byte) error {
+	if len(rom) < 0x10 || string(rom[:4]) != "NES\x1a" {
+		return errors.New("not an iNES ROM")
+	}
+	prgSize := 0x4000 * int(rom[4])
+	chrSize := 0x2000 * int(rom[5])
+	if prgSize == 0 || len(rom) < 0x10+prgSize+chrSize {
+		return errors.New("ROM is truncated")
+	}
+	switch mapperNum := int(rom[6] >> 4); mapperNum {
+	case 0, 2, 3, 4:
+		return nil
+	default:
+		return fmt.Errorf("mapper %d is not supported", mapperNum)
+	}
 }
 
 type INESHeader struct {
@@ -105,3 +133,27 @@ func (c *Cartridge) GetSaveData() []byte {
 func (c *Cartridge) IsVerticallyMirrored() bool {
 	return c.Header.IsVerticallyMirrored
 }
+
+func (c *Cartridge) SaveState() MapperState {
+	return c.Mapper.SaveState()
+}
+
+func (c *Cartridge) LoadState(s MapperState) error {
+	return c.Mapper.LoadState(s)
+}
+
+// checkRegs reports an error unless the state has n registers.
+func checkRegs(name string, s MapperState, n int) error {
+	if len(s.Regs) != n {
+		return fmt.Errorf("%s: save state has %d registers, want %d", name, len(s.Regs), n)
+	}
+	return nil
+}
+
+// checkBank fails unless banks [bank, bank+span) all exist in a ROM of n banks.
+func checkBank(name string, bank, span, n int) error {
+	if bank < 0 || bank+span > n {
+		return fmt.Errorf("%s: save state selects bank %d of %d", name, bank, n)
+	}
+	return nil
+}
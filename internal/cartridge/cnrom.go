@@ -53,6 +53,21 @@ func (c *CNROM) GetSaveData() []byte {
 	return []byte{}
 }
 
+func (c *CNROM) SaveState() MapperState {
+	return MapperState{Regs: []int{c.bank}}
+}
+
+func (c *CNROM) LoadState(s MapperState) error {
+	if err := checkRegs("cnrom", s, 1); err != nil {
+		return err
+	}
+	if err := checkBank("cnrom", s.Regs[0], 1, len(c.chrROM)/0x2000); err != nil {
+		return err
+	}
+	c.bank = s.Regs[0]
+	return nil
+}
+
 func (c *CNROM) IsVerticallyMirrored() bool {
 	return c.isVerticallyMirrored
 }
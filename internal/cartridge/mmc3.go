@@ -1,5 +1,7 @@
 package cartridge
 
+import "fmt"
+
 type MMC3 struct {
 	prgROM               []byte
 	chrROM               []byte
@@ -140,6 +142,48 @@ func (m *MMC3) GetSaveData() []byte {
 	return []byte{}
 }
 
+// Regs = nextWrite, prgBankMode, chrInversion, r[0] ~ r[7]
+func (m *MMC3) SaveState() MapperState {
+	regs := []int{m.nextWrite, m.prgBankMode, m.chrInversion}
+	regs = append(regs, m.r[:]...)
+	return MapperState{Regs: regs}
+}
+
+func (m *MMC3) LoadState(s MapperState) error {
+	if err := checkRegs("mmc3", s, 3+len(m.r)); err != nil {
+		return err
+	}
+	if err := checkBank("mmc3", s.Regs[0], 1, len(m.r)); err != nil {
+		return err
+	}
+	for _, mode := range s.Regs[1:3] {
+		if mode != 0 && mode != 1 {
+			return fmt.Errorf("mmc3: save state has bank mode %d", mode)
+		}
+	}
+	// r[0], r[1] select 2K CHR banks, r[2] ~ r[5] 1K CHR banks,
+	// r[6], r[7] 8K PRG banks.
+	for i, bank := range s.Regs[3:] {
+		var err error
+		switch i {
+		case 0, 1:
+			err = checkBank("mmc3", bank, 2, len(m.chrROM)/0x400)
+		case 6, 7:
+			err = checkBank("mmc3", bank, 1, len(m.prgROM)/0x2000)
+		default:
+			err = checkBank("mmc3", bank, 1, len(m.chrROM)/0x400)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	m.nextWrite = s.Regs[0]
+	m.prgBankMode = s.Regs[1]
+	m.chrInversion = s.Regs[2]
+	copy(m.r[:], s.Regs[3:])
+	return nil
+}
+
 func (m *MMC3) IsVerticallyMirrored() bool {
 	return m.isVerticallyMirrored
 }
@@ -50,6 +50,14 @@ func (n *NROM) GetSaveData() []byte {
 	return []byte{}
 }
 
+func (n *NROM) SaveState() MapperState {
+	return MapperState{}
+}
+
+func (n *NROM) LoadState(s MapperState) error {
+	return checkRegs("nrom", s, 0)
+}
+
 func (n *NROM) IsVerticallyMirrored() bool {
 	return n.isVerticallyMirrored
 }
@@ -61,6 +61,21 @@ func (u *UxROM) GetSaveData() []byte {
 	return []byte{}
 }
 
+func (u *UxROM) SaveState() MapperState {
+	return MapperState{Regs: []int{u.bank}}
+}
+
+func (u *UxROM) LoadState(s MapperState) error {
+	if err := checkRegs("uxrom", s, 1); err != nil {
+		return err
+	}
+	if err := checkBank("uxrom", s.Regs[0], 1, u.header.TotalPRGROMUnits); err != nil {
+		return err
+	}
+	u.bank = s.Regs[0]
+	return nil
+}
+
 func (u *UxROM) IsVerticallyMirrored() bool {
 	return u.isVerticallyMirrored
 }
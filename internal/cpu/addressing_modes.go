@@ -1,5 +1,13 @@
 package cpu
 
+// Every cycle of the 6502 is a bus access. The addressing modes make the
+// same dummy reads as the hardware while it computes an address.
+
+// Implied and accumulator instructions read the next byte and discard it.
+func (c *CPU) implied() {
+	c.dummyRead(c.pc)
+}
+
 func (c *CPU) immediate() uint16 {
 	addr := c.pc
 	c.pc += 1
@@ -11,14 +19,17 @@ func (c *CPU) zeroPage() uint16 {
 	return zp
 }
 
+// The base address is read while the index is added.
 func (c *CPU) zeroPageX() uint16 {
-	zp := uint16((c.fetch() + c.x) & 0xFF)
-	return zp
+	base := c.fetch()
+	c.dummyRead(uint16(base))
+	return uint16(base + c.x)
 }
 
 func (c *CPU) zeroPageY() uint16 {
-	zp := uint16((c.fetch() + c.y) & 0xFF)
-	return zp
+	base := c.fetch()
+	c.dummyRead(uint16(base))
+	return uint16(base + c.y)
 }
 
 func (c *CPU) absolute() uint16 {
@@ -27,30 +38,24 @@ func (c *CPU) absolute() uint16 {
 	return hi<<8 + lo
 }
 
-func (c *CPU) absoluteX() (uint16, int) {
-	lo := uint16(c.fetch())
-	hi := uint16(c.fetch())
-	base := hi<<8 + lo
-	addr := base + uint16(c.x)
+// Indexed modes first read from the address whose high byte is not yet
+// carried into. Read instructions skip this cycle when no page is crossed;
+// writes and read-modify-writes (the ForWrite variants) always take it.
 
-	oops := 0
-	if base&0xFF00 != addr&0xFF00 {
-		oops = 1
-	}
-	return addr, oops
+func (c *CPU) absoluteX() uint16 {
+	return c.indexed(c.absolute(), c.x, false)
 }
 
-func (c *CPU) absoluteY() (uint16, int) {
-	lo := uint16(c.fetch())
-	hi := uint16(c.fetch())
-	base := hi<<8 + lo
-	addr := base + uint16(c.y)
+func (c *CPU) absoluteXForWrite() uint16 {
+	return c.indexed(c.absolute(), c.x, true)
+}
 
-	oops := 0
-	if base&0xFF00 != addr&0xFF00 {
-		oops = 1
-	}
-	return addr, oops
+func (c *CPU) absoluteY() uint16 {
+	return c.indexed(c.absolute(), c.y, false)
+}
+
+func (c *CPU) absoluteYForWrite() uint16 {
+	return c.indexed(c.absolute(), c.y, true)
 }
 
 func (c *CPU) indirect() uint16 {
@@ -61,35 +66,41 @@ func (c *CPU) indirect() uint16 {
 	addrHi := uint16(c.read((ptr & 0xFF00) + ((ptr + 1) & 0x00FF))) // 6502 bug
 	return addrHi<<8 + addrLo
 }
+
 func (c *CPU) indirectX() uint16 {
-	zp := uint16((c.fetch() + c.x) & 0xFF)
+	base := c.fetch()
+	c.dummyRead(uint16(base))
+	zp := uint16(base + c.x)
 	lo := uint16(c.read(zp))
 	hi := uint16(c.read((zp + 1) & 0xFF))
 	return hi<<8 + lo
 }
 
-func (c *CPU) indirectY() (uint16, int) {
+func (c *CPU) indirectY() uint16 {
+	return c.indexed(c.indirectBase(), c.y, false)
+}
+
+func (c *CPU) indirectYForWrite() uint16 {
+	return c.indexed(c.indirectBase(), c.y, true)
+}
+
+func (c *CPU) indirectBase() uint16 {
 	zp := uint16(c.fetch())
 	lo := uint16(c.read(zp))
 	hi := uint16(c.read((zp + 1) & 0xFF))
-	base := hi<<8 + lo
-	addr := base + uint16(c.y)
+	return hi<<8 + lo
+}
 
-	oops := 0
-	if base&0xFF00 != addr&0xFF00 {
-		oops = 1
+func (c *CPU) indexed(base uint16, index byte, isWrite bool) uint16 {
+	addr := base + uint16(index)
+	if isWrite || base&0xFF00 != addr&0xFF00 {
+		c.dummyRead(base&0xFF00 | addr&0x00FF)
 	}
-	return addr, oops
+	return addr
 }
 
-func (c *CPU) relative() (uint16, int) {
+func (c *CPU) relative() uint16 {
 	offset := int8(c.fetch())
 	addr := uint16(int32(c.pc) + int32(offset))
-
-	oops := 0
-	if c.pc&0xFF00 != addr&0xFF00 {
-		oops = 1
-	}
-
-	return addr, oops
+	return addr
 }
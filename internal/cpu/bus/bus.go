@@ -1,38 +1,93 @@
 package bus
 
 import (
+	"nesutaro/internal/apu"
 	"nesutaro/internal/cartridge"
 	"nesutaro/internal/joypad"
 	"nesutaro/internal/ppu"
+	"nesutaro/internal/region"
 )
 
 type Bus struct {
 	Cart      *cartridge.Cartridge
 	PPU       *ppu.PPU
-	Joypad    *joypad.Joypad
+	APU       *apu.APU
+	Ports     [2]joypad.Device // $4016, $4017 (nil = nothing plugged)
 	wram      [0x800]byte
-	reg0x4010 byte
+	openBus   byte // Last value on the data bus, returned by undriven bits
 	reg0x4017 byte
-	HasIRQ    bool
+	isStrobed bool // The controllers were strobed since ClearStrobed()
+
+	// IRQ line and APU frame counter (irq.go)
+	irqSources         IRQSource
+	timing             region.Timing
+	frameCounterCycles int
+
+	cycles uint64 // CPU cycles since power-on; even ones are DMA get cycles
+
+	// DMA (dma.go)
+	oamDMAPage       byte
+	isOAMDMARunning  bool
+	dmcDMAAddr       uint16
+	dmcDMADone       func(val byte)
+	isDMCDMARunning  bool
+	isDMAHaltNeeded  bool
+	isDMCDummyNeeded bool
 }
 
 const ()
 
-func NewBus(cart *cartridge.Cartridge, p *ppu.PPU, j *joypad.Joypad) *Bus {
+func NewBus(cart *cartridge.Cartridge, p *ppu.PPU) *Bus {
 	bus := &Bus{
 		Cart:   cart,
 		PPU:    p,
-		Joypad: j,
+		timing: region.NTSC.Timing(),
 	}
+	bus.APU = apu.NewAPU(bus.RequestDMCDMA, func() { bus.SetIRQ(IRQDMC) })
 
 	return bus
 }
 
+// IsStrobed reports whether the game strobed the controllers ($4016 bit 0)
+// since the last ClearStrobed(). Frames without a strobe are lag frames.
+func (b *Bus) IsStrobed() bool {
+	return b.isStrobed
+}
+
+func (b *Bus) ClearStrobed() {
+	b.isStrobed = false
+}
+
+// Tick advances the rest of the system by one CPU cycle.
+// The CPU calls it before each of its bus accesses.
+func (b *Bus) Tick() {
+	b.cycles++
+	b.PPU.Step(1)
+	b.APU.Step()
+	b.stepFrameCounter()
+}
+
+// The region sets the period of the APU frame counter and the APU timing.
+func (b *Bus) SetRegion(r region.Region) {
+	b.timing = r.Timing()
+	b.APU.SetRegion(r)
+}
+
 func (b *Bus) Read(addr uint16) byte {
+	val := b.read(addr)
+	if addr != 0x4015 { // $4015 is inside the CPU and does not drive the data bus.
+		b.openBus = val
+	}
+	return val
+}
+
+func (b *Bus) read(addr uint16) byte {
 	switch {
 	case addr <= 0x1FFF:
 		return b.wram[addr&0x07FF]
 
+	case addr == 0x2000 || addr == 0x2001 || addr == 0x2003 || addr == 0x2005 || addr == 0x2006:
+		return b.PPU.ReadIOLatch()
 	case addr == 0x2002:
 		return b.PPU.ReadPPUSTATUS()
 	case addr == 0x2004:
@@ -40,15 +95,12 @@ func (b *Bus) Read(addr uint16) byte {
 	case addr == 0x2007:
 		return b.PPU.ReadPPUDATA()
 	case 0x2008 <= addr && addr <= 0x3FFF:
-		return b.Read(0x2000 + addr&7)
+		return b.read(0x2000 + addr&7)
 
 	case addr == 0x4015:
-		if b.reg0x4017>>6&0x03 == 0 {
-			b.HasIRQ = true
-		}
-		return b.reg0x4017
-	case addr == 0x4016:
-		return b.Joypad.Read4016()
+		return b.readAPUStatus()
+	case addr == 0x4016 || addr == 0x4017:
+		return b.openBus&0xE0 | b.readPort(int(addr-0x4016))&0x1F
 
 	case 0x6000 <= addr && addr <= 0x7FFF:
 		return b.Cart.ReadPRGRAM(addr)
@@ -59,11 +111,19 @@ func (b *Bus) Read(addr uint16) byte {
 			return b.Cart.ReadPRGROM(addr)
 		}
 	default:
-		return 0xFF
+		return b.openBus
+	}
+}
+
+func (b *Bus) readPort(i int) byte {
+	if b.Ports[i] == nil {
+		return 0
 	}
+	return b.Ports[i].Read()
 }
 
 func (b *Bus) Write(addr uint16, val byte) {
+	b.openBus = val
 	switch {
 	case addr <= 0x1FFF:
 		b.wram[addr&0x07FF] = val
@@ -85,29 +145,31 @@ func (b *Bus) Write(addr uint16, val byte) {
 	case 0x2008 <= addr && addr <= 0x3FFF:
 		b.Write(0x2000+addr&7, val)
 
-	case addr == 0x4010:
-		if b.reg0x4010>>7&1 == 0 && val>>7&1 == 1 {
-			b.HasIRQ = true
+	case 0x4000 <= addr && addr <= 0x4013:
+		if addr == 0x4010 && val&0x80 == 0 {
+			b.AcknowledgeIRQ(IRQDMC)
 		}
-		b.reg0x4010 = val
+		b.APU.Write(addr, val)
 
 	case addr == 0x4014:
-		var dmaData [256]byte
-		for i := 0; i < 256; i++ {
-			dmaData[i] = b.Read(uint16(val)<<8 + uint16(i))
-		}
-		b.PPU.WriteOAMDMA(&dmaData)
+		b.RequestOAMDMA(val)
 
 	case addr == 0x4015:
-		if b.reg0x4010>>7&1 == 1 {
-			b.HasIRQ = true
-		}
+		b.AcknowledgeIRQ(IRQDMC)
+		b.APU.WriteStatus(val)
 
 	case addr == 0x4016:
-		b.Joypad.Write4016(val)
+		if val&1 == 1 {
+			b.isStrobed = true
+		}
+		for _, d := range b.Ports {
+			if d != nil {
+				d.Write(val)
+			}
+		}
 
 	case addr == 0x4017:
-		b.reg0x4017 = val
+		b.writeFrameCounter(val)
 
 	case 0x6000 <= addr && addr <= 0x7FFF:
 		b.Cart.WritePRGRAM(addr, val)
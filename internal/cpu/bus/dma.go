@@ -0,0 +1,89 @@
+package bus
+
+// The DMA unit takes the bus over from the CPU. It can only halt the CPU on
+// a read cycle, and the halted CPU keeps repeating that read until the DMA
+// is done.
+//
+// The bus alternates between get (read) and put (write) cycles:
+//   - OAM DMA: 1 halt cycle, 1 alignment cycle if needed, then 256 get/put
+//     pairs copying a page to $2004. 513 or 514 cycles in total.
+//   - DMC DMA: 1 halt cycle, 1 dummy cycle, 1 alignment cycle if needed, then
+//     the sample is read on a get cycle. During an OAM DMA, the OAM cycles
+//     count as its halt and dummy cycles and it steals a get cycle (usually
+//     costing 2 more cycles in total).
+//
+// Joypad registers only see the first of consecutive reads, so the repeated
+// reads are skipped for them, but the CPU read that resumes after the DMA
+// clocks the shift register once more. This is the DMC DMA bit deletion
+// that games work around by reading the controllers until two reads agree.
+
+// RequestOAMDMA starts copying the page $XX00 ~ $XXFF to OAM at OAMADDR.
+func (b *Bus) RequestOAMDMA(page byte) {
+	b.oamDMAPage = page
+	b.isOAMDMARunning = true
+	b.isDMAHaltNeeded = true
+}
+
+// RequestDMCDMA schedules a DMC sample fetch from addr. done receives the byte
+// once it is read. It is the hook for the DMC channel of the APU.
+func (b *Bus) RequestDMCDMA(addr uint16, done func(val byte)) {
+	b.dmcDMAAddr = addr
+	b.dmcDMADone = done
+	b.isDMCDMARunning = true
+	b.isDMAHaltNeeded = true
+	b.isDMCDummyNeeded = true
+}
+
+func (b *Bus) IsDMAPending() bool {
+	return b.isDMAHaltNeeded
+}
+
+// RunDMA runs the pending DMAs before the CPU reads addr.
+// It returns the number of CPU cycles they took.
+func (b *Bus) RunDMA(addr uint16) int {
+	if !b.isDMAHaltNeeded {
+		return 0
+	}
+	isJoypadRead := addr == 0x4016 || addr == 0x4017
+
+	cycles := 1
+	b.Tick()
+	b.Read(addr) // Halt cycle
+	b.isDMAHaltNeeded = false
+
+	oamCount := 0 // Gets and puts done
+	var oamVal byte
+	for b.isDMCDMARunning || b.isOAMDMARunning {
+		isDMCReady := b.isDMCDMARunning && !b.isDMAHaltNeeded && !b.isDMCDummyNeeded
+		// Any cycle counts as a halt or dummy cycle of a DMC DMA.
+		if b.isDMAHaltNeeded {
+			b.isDMAHaltNeeded = false
+		} else if b.isDMCDummyNeeded {
+			b.isDMCDummyNeeded = false
+		}
+		cycles++
+		b.Tick()
+		isGetCycle := b.cycles&1 == 0
+
+		switch {
+		case isGetCycle && isDMCReady:
+			val := b.Read(b.dmcDMAAddr)
+			b.isDMCDMARunning = false
+			if b.dmcDMADone != nil {
+				b.dmcDMADone(val)
+			}
+		case isGetCycle && b.isOAMDMARunning && oamCount&1 == 0:
+			oamVal = b.Read(uint16(b.oamDMAPage)<<8 | uint16(oamCount>>1))
+			oamCount++
+		case !isGetCycle && b.isOAMDMARunning && oamCount&1 == 1:
+			b.Write(0x2004, oamVal)
+			oamCount++
+			if oamCount == 512 {
+				b.isOAMDMARunning = false
+			}
+		case !isJoypadRead:
+			b.Read(addr) // Halt, dummy or alignment cycle
+		}
+	}
+	return cycles
+}
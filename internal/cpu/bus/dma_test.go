@@ -0,0 +1,97 @@
+package bus
+
+import (
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/ppu"
+	pbus "nesutaro/internal/ppu/bus"
+	"testing"
+)
+
+// newTestBus returns a bus on an NROM cartridge whose cycle counter has the
+// given parity: the next cycle is a get cycle if it is odd.
+func newTestBus(cycles uint64) *Bus {
+	rom := make([]byte, 0x10+0x4000+0x2000)
+	copy(rom, "NES\x1a\x01\x01")
+	cart := cartridge.NewCartridge(rom)
+	b := NewBus(cart, ppu.NewPPU(pbus.NewBus(cart)))
+	b.cycles = cycles
+	for i := 0; i < 0x100; i++ {
+		b.wram[0x200+i] = byte(i) ^ 0x5A
+	}
+	b.wram[0x123] = 0xC3
+	return b
+}
+
+func checkOAM(t *testing.T, b *Bus) {
+	t.Helper()
+	for i := 0; i < 0x100; i++ {
+		if v := b.PPU.ReadOAM(uint16(i)); v != byte(i)^0x5A {
+			t.Fatalf("OAM[%02X] = %02X, want %02X", i, v, byte(i)^0x5A)
+		}
+	}
+}
+
+func TestDMACycles(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		isOAM, isDMC bool
+		want         [2]int // From an even and an odd cycle
+	}{
+		{"OAM", true, false, [2]int{513, 514}},
+		{"DMC", false, true, [2]int{4, 3}},
+		// The DMC fetch steals a get cycle and the put cycle after it.
+		{"OAM+DMC", true, true, [2]int{515, 516}},
+	} {
+		for parity, want := range tt.want {
+			b := newTestBus(uint64(parity))
+			var dmcVal byte
+			isDMCDone := false
+			if tt.isOAM {
+				b.RequestOAMDMA(0x02)
+			}
+			if tt.isDMC {
+				b.RequestDMCDMA(0x0123, func(val byte) { dmcVal, isDMCDone = val, true })
+			}
+			if !b.IsDMAPending() {
+				t.Fatalf("%s: no DMA pending", tt.name)
+			}
+			if cycles := b.RunDMA(0x8000); cycles != want {
+				t.Errorf("%s from cycle %d: %d cycles, want %d", tt.name, parity, cycles, want)
+			}
+			if b.IsDMAPending() {
+				t.Errorf("%s: DMA still pending", tt.name)
+			}
+			if tt.isOAM {
+				checkOAM(t, b)
+			}
+			if tt.isDMC && (!isDMCDone || dmcVal != 0xC3) {
+				t.Errorf("%s: DMC got %02X (done %v), want C3", tt.name, dmcVal, isDMCDone)
+			}
+		}
+	}
+	if cycles := newTestBus(0).RunDMA(0x8000); cycles != 0 {
+		t.Errorf("RunDMA without a request took %d cycles", cycles)
+	}
+}
+
+// A DMC fetch pending in a save state runs after it is loaded,
+// with the callback of the bus it is loaded into.
+func TestDMCDMASaveState(t *testing.T) {
+	b := newTestBus(0)
+	b.RequestDMCDMA(0x0123, nil)
+	s := b.SaveState()
+
+	loaded := newTestBus(0)
+	var dmcVal byte
+	loaded.dmcDMADone = func(val byte) { dmcVal = val }
+	loaded.LoadState(s)
+	if !loaded.IsDMAPending() {
+		t.Fatal("the DMC DMA is not pending after LoadState")
+	}
+	if cycles := loaded.RunDMA(0x8000); cycles != 4 {
+		t.Errorf("%d cycles, want 4", cycles)
+	}
+	if dmcVal != 0xC3 {
+		t.Errorf("DMC got %02X, want C3", dmcVal)
+	}
+}
@@ -0,0 +1,107 @@
+package bus
+
+// IRQSource is a device driving the IRQ line.
+// The line is level-triggered: it is asserted while any source is.
+type IRQSource byte
+
+const (
+	IRQFrameCounter IRQSource = 1 << iota // APU frame counter ($4017)
+	IRQDMC                                // APU DMC channel ($4010)
+	IRQMapper                             // Cartridge (MMC3, VRC, FME-7, ...)
+	IRQExternal                           // Expansion port devices
+)
+
+// SetIRQ asserts the IRQ line for src until src acknowledges it.
+func (b *Bus) SetIRQ(src IRQSource) {
+	b.irqSources |= src
+}
+
+// AcknowledgeIRQ releases the IRQ line for src. Each source does it in its
+// own way, e.g. a $4015 read for the frame counter or a register write for a mapper.
+// Taking the interrupt does not acknowledge it.
+func (b *Bus) AcknowledgeIRQ(src IRQSource) {
+	b.irqSources &^= src
+}
+
+func (b *Bus) IsIRQSet(src IRQSource) bool {
+	return b.irqSources&src != 0
+}
+
+func (b *Bus) HasIRQ() bool {
+	return b.irqSources != 0
+}
+
+// The NMI line is driven by the PPU alone.
+func (b *Bus) HasNMI() bool {
+	return b.PPU.HasNMI()
+}
+
+func (b *Bus) DisableNMI() {
+	b.PPU.DisableNMI()
+}
+
+// ===== APU frame counter =====
+
+// The frame counter clocks the APU envelopes and linear counter 4 times per
+// period (quarter frames), and its lengths and sweeps 2 times (half frames).
+// In 4-step mode ($4017 bit 7 = 0), the IRQ flag is set on the last cycles of
+// each period unless inhibited ($4017 bit 6 = 1). The 5-step mode has a longer
+// period, no clock on its 4th step and no IRQ.
+
+func (b *Bus) stepFrameCounter() {
+	steps := b.timing.FrameCounterSteps
+	isFiveStep := b.reg0x4017&0x80 != 0
+	period := b.timing.FrameCounterPeriod
+	if isFiveStep {
+		period = steps[4] + 1
+	}
+	b.frameCounterCycles++
+	if b.frameCounterCycles >= period {
+		b.frameCounterCycles = 0
+	}
+	switch b.frameCounterCycles {
+	case steps[0], steps[2]:
+		b.APU.QuarterFrame()
+	case steps[1]:
+		b.APU.QuarterFrame()
+		b.APU.HalfFrame()
+	case steps[3]:
+		if !isFiveStep {
+			b.APU.QuarterFrame()
+			b.APU.HalfFrame()
+		}
+	case steps[4]: // Only reached in 5-step mode
+		b.APU.QuarterFrame()
+		b.APU.HalfFrame()
+	}
+	if b.reg0x4017&0xC0 == 0 && b.frameCounterCycles >= b.timing.FrameCounterPeriod-2 {
+		b.SetIRQ(IRQFrameCounter)
+	}
+}
+
+func (b *Bus) writeFrameCounter(val byte) {
+	b.reg0x4017 = val
+	b.frameCounterCycles = 0
+	if val&0x40 != 0 {
+		b.AcknowledgeIRQ(IRQFrameCounter)
+	}
+	// Entering 5-step mode clocks everything at once.
+	if val&0x80 != 0 {
+		b.APU.QuarterFrame()
+		b.APU.HalfFrame()
+	}
+}
+
+// $4015 reads the channel status of the APU and the IRQ flags (bit 5 is open bus).
+// Reading it acknowledges the frame IRQ.
+func (b *Bus) readAPUStatus() byte {
+	val := b.openBus&0x20 | b.APU.Status()
+	if b.IsIRQSet(IRQFrameCounter) {
+		val |= 0x40
+	}
+	if b.IsIRQSet(IRQDMC) {
+		val |= 0x80
+	}
+	b.AcknowledgeIRQ(IRQFrameCounter)
+	return val
+}
@@ -0,0 +1,55 @@
+package bus
+
+// State is a serializable snapshot of the CPU bus, used for save states.
+type State struct {
+	WRAM               [0x800]byte
+	OpenBus            byte
+	Reg0x4017          byte
+	IRQSources         byte
+	FrameCounterCycles int
+
+	Cycles          uint64
+	OAMDMAPage      byte
+	IsOAMDMARunning bool
+	IsDMAHaltNeeded bool
+	// A pending DMC fetch. Its done callback belongs to the APU and is not
+	// saved: LoadState keeps the one of the bus.
+	DMCDMAAddr       uint16
+	IsDMCDMARunning  bool
+	IsDMCDummyNeeded bool
+}
+
+func (b *Bus) SaveState() State {
+	return State{
+		WRAM:               b.wram,
+		OpenBus:            b.openBus,
+		Reg0x4017:          b.reg0x4017,
+		IRQSources:         byte(b.irqSources),
+		FrameCounterCycles: b.frameCounterCycles,
+
+		Cycles:          b.cycles,
+		OAMDMAPage:      b.oamDMAPage,
+		IsOAMDMARunning: b.isOAMDMARunning,
+		IsDMAHaltNeeded: b.isDMAHaltNeeded,
+
+		DMCDMAAddr:       b.dmcDMAAddr,
+		IsDMCDMARunning:  b.isDMCDMARunning,
+		IsDMCDummyNeeded: b.isDMCDummyNeeded,
+	}
+}
+
+func (b *Bus) LoadState(s State) {
+	b.wram = s.WRAM
+	b.openBus = s.OpenBus
+	b.reg0x4017 = s.Reg0x4017
+	b.irqSources = IRQSource(s.IRQSources)
+	b.frameCounterCycles = s.FrameCounterCycles
+
+	b.cycles = s.Cycles
+	b.oamDMAPage = s.OAMDMAPage
+	b.isOAMDMARunning = s.IsOAMDMARunning
+	b.isDMAHaltNeeded = s.IsDMAHaltNeeded
+	b.dmcDMAAddr = s.DMCDMAAddr
+	b.isDMCDMARunning = s.IsDMCDMARunning
+	b.isDMCDummyNeeded = s.IsDMCDummyNeeded
+}
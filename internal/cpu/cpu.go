@@ -15,9 +15,23 @@ const (
 	NegativeFlagMask         byte = 1 << 7
 )
 
+// The systemBus is what the CPU needs of the bus.
+// It is a *bus.Bus, except in tests.
+type systemBus interface {
+	Read(addr uint16) byte
+	Write(addr uint16, val byte)
+	Tick()
+	IsDMAPending() bool
+	RunDMA(addr uint16) int
+	HasIRQ() bool
+	HasNMI() bool
+	DisableNMI()
+}
+
 type CPU struct {
 	Tracer *Tracer
 	Bus    *bus.Bus
+	sys    systemBus // = Bus
 
 	// Registers
 	a, x, y, s, p byte
@@ -27,7 +41,12 @@ type CPU struct {
 	cycles  int
 	IsPanic bool
 
-	isIFlagToggleDelayed bool
+	// Interrupt lines seen by the previous bus access,
+	// and the interrupts to take before the next instruction.
+	isNMILine   bool
+	isNMIPolled bool
+	isIRQLine   bool
+	isIRQPolled bool
 
 	testcnt int
 }
@@ -35,11 +54,12 @@ type CPU struct {
 func NewCPU(b *bus.Bus) *CPU {
 	c := &CPU{
 		Bus: b,
+		sys: b,
 		s:   0xFD,
 		p:   0x24,
 	}
-	lo := uint16(c.read(0xFFFC))
-	hi := uint16(c.read(0xFFFD))
+	lo := uint16(c.sys.Read(0xFFFC))
+	hi := uint16(c.sys.Read(0xFFFD))
 	c.pc = hi<<8 | lo
 	return c
 }
@@ -48,15 +68,13 @@ func (c *CPU) Step() int {
 	c.cycles = 0
 
 	//prevPC := c.pc
-	if c.Bus.PPU.HasNMI() {
-		c.nmi()
+	if c.isNMIPolled {
+		c.interrupt(false, true)
+		return c.cycles
 	}
-	if c.Bus.HasIRQ && c.p&InterruptDisableFlagMask == 0 {
-		c.irq()
-	}
-	if c.isIFlagToggleDelayed {
-		c.p |= InterruptDisableFlagMask
-		c.isIFlagToggleDelayed = false
+	if c.isIRQPolled {
+		c.interrupt(false, false)
+		return c.cycles
 	}
 	op := c.fetch()
 
@@ -80,12 +98,41 @@ func (c *CPU) Step() int {
 	return c.cycles
 }
 
+// Every bus access takes a CPU cycle, and the rest of the system is
+// ticked before it. Reads and writes of PPU registers therefore land on
+// the right dot. A pending DMA halts the CPU on its next read.
 func (c *CPU) read(addr uint16) byte {
-	return c.Bus.Read(addr)
+	if c.sys.IsDMAPending() {
+		c.cycles += c.sys.RunDMA(addr)
+	}
+	c.cycles++
+	c.sys.Tick()
+	v := c.sys.Read(addr)
+	c.pollInterrupts()
+	return v
 }
 
 func (c *CPU) write(addr uint16, val byte) {
-	c.Bus.Write(addr, val)
+	c.cycles++
+	c.sys.Tick()
+	c.sys.Write(addr, val)
+	c.pollInterrupts()
+}
+
+// The CPU reads a value only to spend a cycle (and trigger read side effects).
+func (c *CPU) dummyRead(addr uint16) {
+	c.read(addr)
+}
+
+// The interrupt lines are sampled on every cycle, but an interrupt is taken
+// only if it was seen before the last cycle of an instruction.
+// The IRQ line is masked by the I flag at that time, so CLI, SEI and PLP
+// affect IRQs one instruction late (RTI affects them right away).
+func (c *CPU) pollInterrupts() {
+	c.isNMIPolled = c.isNMILine
+	c.isNMILine = c.sys.HasNMI()
+	c.isIRQPolled = c.isIRQLine
+	c.isIRQLine = c.sys.HasIRQ() && c.p&InterruptDisableFlagMask == 0
 }
 
 func (c *CPU) fetch() byte {
@@ -94,29 +141,17 @@ func (c *CPU) fetch() byte {
 	return v
 }
 
-func (c *CPU) nmi() {
-	hi := byte(c.pc >> 8)
-	c.write(0x0100+uint16(c.s), hi)
-	c.s -= 1
-
-	lo := byte(c.pc & 0x00FF)
-	c.write(0x0100+uint16(c.s), lo)
-	c.s -= 1
-
-	p := c.p &^ TheBFlagMask
-	c.write(0x100+uint16(c.s), p)
-	c.s -= 1
-
-	c.p |= InterruptDisableFlagMask
-	nextLo := uint16(c.read(0xFFFA))
-	nextHi := uint16(c.read(0xFFFB))
-	c.pc = nextHi<<8 | nextLo
-
-	c.Bus.PPU.DisableNMI()
-	c.Bus.HasIRQ = false
-}
+// BRK, IRQ and NMI share one sequence. The vector is chosen after the
+// return address is pushed, so an NMI raised by then hijacks a BRK or IRQ
+// (the pushed B flag still tells a BRK).
+func (c *CPU) interrupt(isBRK, isNMI bool) {
+	if isBRK {
+		c.fetch()
+	} else {
+		c.dummyRead(c.pc)
+		c.dummyRead(c.pc)
+	}
 
-func (c *CPU) irq() {
 	hi := byte(c.pc >> 8)
 	c.write(0x0100+uint16(c.s), hi)
 	c.s -= 1
@@ -125,14 +160,26 @@ func (c *CPU) irq() {
 	c.write(0x0100+uint16(c.s), lo)
 	c.s -= 1
 
+	isNMI = isNMI || c.isNMILine
 	p := c.p &^ TheBFlagMask
+	if isBRK {
+		p |= TheBFlagMask
+	}
 	c.write(0x100+uint16(c.s), p)
 	c.s -= 1
 
 	c.p |= InterruptDisableFlagMask
-	nextLo := uint16(c.read(0xFFFE))
-	nextHi := uint16(c.read(0xFFFF))
+	vector := uint16(0xFFFE)
+	if isNMI {
+		c.sys.DisableNMI()
+		c.isNMILine = false
+		vector = 0xFFFA
+	}
+	nextLo := uint16(c.read(vector))
+	nextHi := uint16(c.read(vector + 1))
 	c.pc = nextHi<<8 | nextLo
 
-	c.Bus.HasIRQ = false
+	// The first instruction of the handler always runs.
+	c.isNMIPolled = false
+	c.isIRQPolled = false
 }
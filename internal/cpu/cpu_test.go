@@ -0,0 +1,277 @@
+package cpu
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Handlers of the test programs
+const (
+	irqHandler = 0x9000
+	nmiHandler = 0xA000
+)
+
+type access struct {
+	addr    uint16
+	val     byte
+	isWrite bool
+}
+
+func (a access) String() string {
+	if a.isWrite {
+		return fmt.Sprintf("W %04X=%02X", a.addr, a.val)
+	}
+	return fmt.Sprintf("R %04X", a.addr)
+}
+
+// mockBus is a flat 64K memory logging every access.
+// The IRQ line is asserted from cycle irqAt on, and the NMI is raised on
+// cycle nmiAt (0 = never). Cycle 1 is the first access.
+type mockBus struct {
+	mem    [0x10000]byte
+	log    []access
+	cycles int
+	irqAt  int
+	nmiAt  int
+	hasNMI bool
+}
+
+func (m *mockBus) Read(addr uint16) byte {
+	m.log = append(m.log, access{addr: addr})
+	return m.mem[addr]
+}
+
+func (m *mockBus) Write(addr uint16, val byte) {
+	m.log = append(m.log, access{addr, val, true})
+	m.mem[addr] = val
+}
+
+func (m *mockBus) Tick() {
+	m.cycles++
+	if m.cycles == m.nmiAt {
+		m.hasNMI = true
+	}
+}
+
+func (m *mockBus) IsDMAPending() bool     { return false }
+func (m *mockBus) RunDMA(addr uint16) int { return 0 }
+func (m *mockBus) HasIRQ() bool           { return m.irqAt > 0 && m.cycles >= m.irqAt }
+func (m *mockBus) HasNMI() bool           { return m.hasNMI }
+func (m *mockBus) DisableNMI()            { m.hasNMI = false }
+
+// newTestCPU returns a CPU at pc running prog on a mock bus.
+// The rest of the PRG space is NOPs.
+func newTestCPU(pc uint16, prog ...byte) (*CPU, *mockBus) {
+	m := &mockBus{}
+	for addr := 0x8000; addr < 0xFFFA; addr++ {
+		m.mem[addr] = 0xEA
+	}
+	copy(m.mem[pc:], prog)
+	m.mem[0xFFFA], m.mem[0xFFFB] = nmiHandler&0xFF, nmiHandler>>8
+	m.mem[0xFFFE], m.mem[0xFFFF] = irqHandler&0xFF, irqHandler>>8
+	c := &CPU{sys: m, s: 0xFD, p: 0x24, pc: pc}
+	return c, m
+}
+
+func r(addr uint16) access           { return access{addr: addr} }
+func w(addr uint16, val byte) access { return access{addr, val, true} }
+
+func TestBusAccesses(t *testing.T) {
+	tests := []struct {
+		name string
+		prog []byte
+		x, y byte
+		zp   map[uint16]byte
+		want []access
+	}{
+		{"NOP", []byte{0xEA}, 0, 0, nil,
+			[]access{r(0x8000), r(0x8001)}},
+		{"LDA zp,X wraps", []byte{0xB5, 0x10}, 0xF8, 0, nil,
+			[]access{r(0x8000), r(0x8001), r(0x0010), r(0x0008)}},
+		{"LDA abs,X", []byte{0xBD, 0x00, 0x20}, 0x01, 0, nil,
+			[]access{r(0x8000), r(0x8001), r(0x8002), r(0x2001)}},
+		{"LDA abs,X page cross", []byte{0xBD, 0xF0, 0x20}, 0x20, 0, nil,
+			[]access{r(0x8000), r(0x8001), r(0x8002), r(0x2010), r(0x2110)}},
+		{"STA abs,X", []byte{0x9D, 0x00, 0x20}, 0x01, 0, nil,
+			[]access{r(0x8000), r(0x8001), r(0x8002), r(0x2001), w(0x2001, 0)}},
+		{"LDA (zp),Y page cross", []byte{0xB1, 0x10}, 0, 0x20, map[uint16]byte{0x10: 0xF0, 0x11: 0x20},
+			[]access{r(0x8000), r(0x8001), r(0x0010), r(0x0011), r(0x2010), r(0x2110)}},
+		{"STA (zp),Y", []byte{0x91, 0x10}, 0, 0x01, map[uint16]byte{0x10: 0x00, 0x11: 0x20},
+			[]access{r(0x8000), r(0x8001), r(0x0010), r(0x0011), r(0x2001), w(0x2001, 0)}},
+		{"LDA (zp,X)", []byte{0xA1, 0x10}, 0x02, 0, map[uint16]byte{0x12: 0x34, 0x13: 0x12},
+			[]access{r(0x8000), r(0x8001), r(0x0010), r(0x0012), r(0x0013), r(0x1234)}},
+		// Read-modify-write instructions write the old value back first.
+		{"INC abs", []byte{0xEE, 0x00, 0x03}, 0, 0, map[uint16]byte{0x300: 0x41},
+			[]access{r(0x8000), r(0x8001), r(0x8002), r(0x0300), w(0x0300, 0x41), w(0x0300, 0x42)}},
+		{"ASL abs,X", []byte{0x1E, 0x00, 0x03}, 0x01, 0, map[uint16]byte{0x301: 0x41},
+			[]access{r(0x8000), r(0x8001), r(0x8002), r(0x0301), r(0x0301), w(0x0301, 0x41), w(0x0301, 0x82)}},
+		{"DEC zp", []byte{0xC6, 0x10}, 0, 0, map[uint16]byte{0x10: 0x00},
+			[]access{r(0x8000), r(0x8001), r(0x0010), w(0x0010, 0x00), w(0x0010, 0xFF)}},
+		{"PHA", []byte{0x48}, 0, 0, nil,
+			[]access{r(0x8000), r(0x8001), w(0x01FD, 0)}},
+		{"PLA", []byte{0x68}, 0, 0, nil,
+			[]access{r(0x8000), r(0x8001), r(0x01FD), r(0x01FE)}},
+		{"RTS", []byte{0x60}, 0, 0, map[uint16]byte{0x1FE: 0x33, 0x1FF: 0x82},
+			[]access{r(0x8000), r(0x8001), r(0x01FD), r(0x01FE), r(0x01FF), r(0x8233)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, m := newTestCPU(0x8000, tt.prog...)
+			c.x, c.y = tt.x, tt.y
+			for addr, val := range tt.zp {
+				m.mem[addr] = val
+			}
+			cycles := c.Step()
+			if fmt.Sprint(m.log) != fmt.Sprint(tt.want) {
+				t.Errorf("accesses:\n got %v\nwant %v", m.log, tt.want)
+			}
+			if cycles != len(tt.want) {
+				t.Errorf("Step() = %d cycles, want %d", cycles, len(tt.want))
+			}
+		})
+	}
+}
+
+// stepsToIRQ runs c until it enters the IRQ handler,
+// and returns the number of instructions run before.
+func stepsToIRQ(t *testing.T, c *CPU) int {
+	t.Helper()
+	for n := 0; n < 10; n++ {
+		c.Step()
+		if c.pc == irqHandler {
+			return n
+		}
+	}
+	t.Fatal("no IRQ")
+	return 0
+}
+
+// The interrupt lines are polled on the penultimate cycle of each instruction.
+func TestIRQPolling(t *testing.T) {
+	tests := []struct {
+		name  string
+		pc    uint16
+		prog  []byte
+		p     byte
+		irqAt int
+		want  int
+	}{
+		{"NOP penultimate cycle", 0x8000, []byte{0xEA, 0xEA}, 0x20, 3, 2},
+		{"NOP last cycle", 0x8000, []byte{0xEA, 0xEA}, 0x20, 4, 3},
+		{"LDA abs penultimate cycle", 0x8000, []byte{0xAD, 0x00, 0x02}, 0x20, 3, 1},
+		{"LDA abs last cycle", 0x8000, []byte{0xAD, 0x00, 0x02}, 0x20, 4, 2},
+		{"branch not taken", 0x8000, []byte{0xD0, 0x00}, 0x22, 1, 1},
+		// A taken branch without page crossing polls as if it took 2 cycles,
+		// so an IRQ on its penultimate (2nd) cycle waits one more instruction.
+		{"branch taken", 0x8000, []byte{0xD0, 0x00}, 0x20, 1, 1},
+		{"branch taken penultimate cycle", 0x8000, []byte{0xD0, 0x00}, 0x20, 2, 2},
+		{"branch taken page cross", 0x80FB, []byte{0xD0, 0x10}, 0x20, 3, 1},
+		{"branch taken page cross last cycle", 0x80FB, []byte{0xD0, 0x10}, 0x20, 4, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, m := newTestCPU(tt.pc, tt.prog...)
+			c.p = tt.p
+			m.irqAt = tt.irqAt
+			if got := stepsToIRQ(t, c); got != tt.want {
+				t.Errorf("IRQ after %d instructions, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// CLI, SEI and PLP change the I flag after the IRQ is polled,
+// so they take effect one instruction late. RTI takes effect right away.
+func TestIFlagLatency(t *testing.T) {
+	tests := []struct {
+		name string
+		pc   uint16
+		prog []byte
+		p    byte
+		want int
+	}{
+		{"CLI", 0x8000, []byte{0x58}, 0x24, 2},
+		{"SEI", 0x8000, []byte{0x78}, 0x20, 1},
+		{"PLP", 0x8000, []byte{0x28}, 0x24, 2},
+		{"RTI", 0x8800, []byte{0x40}, 0x24, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, m := newTestCPU(tt.pc, tt.prog...)
+			c.p = tt.p
+			c.s = 0xFA
+			m.mem[0x1FB] = 0x20 // P with I clear, for PLP and RTI
+			m.mem[0x1FC] = 0x00 // Return address of RTI
+			m.mem[0x1FD] = 0x80
+			m.irqAt = 1
+			if got := stepsToIRQ(t, c); got != tt.want {
+				t.Errorf("IRQ after %d instructions, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSEIRunsPendingIRQ(t *testing.T) {
+	c, m := newTestCPU(0x8000, 0x78) // SEI
+	c.p = 0x20
+	m.irqAt = 1
+	stepsToIRQ(t, c)
+	// The handler returns with the I flag set.
+	if pushed := m.mem[0x1FB]; pushed&InterruptDisableFlagMask == 0 {
+		t.Errorf("pushed P = %02X, want the I flag set", pushed)
+	}
+}
+
+// An NMI raised before P is pushed takes over the vector of a BRK or IRQ.
+func TestNMIHijacking(t *testing.T) {
+	tests := []struct {
+		name   string
+		isBRK  bool
+		nmiAt  int
+		wantPC uint16
+	}{
+		{"BRK", true, 4, nmiHandler},
+		{"BRK too late", true, 5, irqHandler},
+		{"IRQ", false, 4, nmiHandler},
+		{"IRQ too late", false, 5, irqHandler},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, m := newTestCPU(0x8000, 0x00) // BRK
+			if !tt.isBRK {
+				c.p = 0x20
+				c.isIRQPolled = true
+			}
+			m.nmiAt = tt.nmiAt
+			if cycles := c.Step(); cycles != 7 {
+				t.Errorf("Step() = %d cycles, want 7", cycles)
+			}
+			if c.pc != tt.wantPC {
+				t.Errorf("pc = %04X, want %04X", c.pc, tt.wantPC)
+			}
+			// The pushed B flag still tells a BRK.
+			if isB := m.mem[0x1FB]&TheBFlagMask != 0; isB != tt.isBRK {
+				t.Errorf("pushed B flag = %v, want %v", isB, tt.isBRK)
+			}
+			wantReturn := uint16(0x8000)
+			if tt.isBRK {
+				wantReturn = 0x8002
+			}
+			if ret := uint16(m.mem[0x1FD])<<8 | uint16(m.mem[0x1FC]); ret != wantReturn {
+				t.Errorf("return address = %04X, want %04X", ret, wantReturn)
+			}
+			if tt.wantPC == nmiHandler {
+				if m.hasNMI {
+					t.Error("the NMI is still pending after it was taken")
+				}
+				return
+			}
+			// A late NMI runs after the first instruction of the handler.
+			c.Step()
+			c.Step()
+			if c.pc != nmiHandler {
+				t.Errorf("pc after the handler's first instruction = %04X, want %04X", c.pc, nmiHandler)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package cpu
 
 func (c *CPU) adc(addr uint16) {
+	c.adcValue(c.read(addr))
+}
+
+func (c *CPU) adcValue(mem byte) {
 	a := c.a
-	mem := c.read(addr)
 	result16 := uint16(a) + uint16(mem) + uint16(c.p&CarryFlagMask)
 	result := byte(result16)
 
@@ -73,7 +76,11 @@ func (c *CPU) anc(addr uint16) {
 }
 
 func (c *CPU) and(addr uint16) {
-	result := c.a & c.read(addr)
+	c.andValue(c.read(addr))
+}
+
+func (c *CPU) andValue(val byte) {
+	result := c.a & val
 
 	if result == 0 {
 		c.p |= ZeroFlagMask
@@ -90,8 +97,9 @@ func (c *CPU) and(addr uint16) {
 	c.a = result
 }
 
-func (c *CPU) asl(addr uint16) {
+func (c *CPU) asl(addr uint16) byte {
 	val := c.read(addr)
+	c.write(addr, val) // Dummy write
 
 	if val&0x80 != 0 {
 		c.p |= CarryFlagMask
@@ -113,6 +121,7 @@ func (c *CPU) asl(addr uint16) {
 	}
 
 	c.write(addr, result)
+	return result
 }
 
 func (c *CPU) aslAccum() {
@@ -165,28 +174,37 @@ func (c *CPU) axs(addr uint16) {
 	c.x = result
 }
 
-func (c *CPU) bcc(addr uint16) bool {
-	isBranched := c.p&CarryFlagMask == 0
-	if isBranched {
-		c.pc = addr
+// A taken branch reads the next opcode while adding the offset,
+// and again from the uncarried address when it crosses a page.
+// Interrupts are not polled on the extra cycle of a branch that stays in
+// the page, so one raised during the branch waits another instruction.
+func (c *CPU) branch(addr uint16) {
+	isNMIPolled, isIRQPolled := c.isNMIPolled, c.isIRQPolled
+	c.dummyRead(c.pc)
+	if c.pc&0xFF00 != addr&0xFF00 {
+		c.dummyRead(c.pc&0xFF00 | addr&0x00FF)
+	} else {
+		c.isNMIPolled, c.isIRQPolled = isNMIPolled, isIRQPolled
+	}
+	c.pc = addr
+}
+
+func (c *CPU) bcc(addr uint16) {
+	if c.p&CarryFlagMask == 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
-func (c *CPU) bcs(addr uint16) bool {
-	isBranched := c.p&CarryFlagMask != 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) bcs(addr uint16) {
+	if c.p&CarryFlagMask != 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
-func (c *CPU) beq(addr uint16) bool {
-	isBranched := c.p&ZeroFlagMask != 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) beq(addr uint16) {
+	if c.p&ZeroFlagMask != 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
 func (c *CPU) bit(addr uint16) {
@@ -210,61 +228,38 @@ func (c *CPU) bit(addr uint16) {
 	}
 }
 
-func (c *CPU) bmi(addr uint16) bool {
-	isBranched := c.p&NegativeFlagMask != 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) bmi(addr uint16) {
+	if c.p&NegativeFlagMask != 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
-func (c *CPU) bne(addr uint16) bool {
-	isBranched := c.p&ZeroFlagMask == 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) bne(addr uint16) {
+	if c.p&ZeroFlagMask == 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
-func (c *CPU) bpl(addr uint16) bool {
-	isBranched := c.p&NegativeFlagMask == 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) bpl(addr uint16) {
+	if c.p&NegativeFlagMask == 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
 func (c *CPU) brk() {
-	c.fetch()
-	pushLo := byte(c.pc & 0x00FF)
-	pushHi := byte(c.pc >> 8)
-	c.write(0x0100+uint16(c.s), pushHi)
-	c.s -= 1
-	c.write(0x0100+uint16(c.s), pushLo)
-	c.s -= 1
-	c.write(0x0100+uint16(c.s), c.p|0x30)
-	c.s -= 1
-	lo := uint16(c.read(0xFFFE))
-	hi := uint16(c.read(0xFFFF))
-	c.pc = hi<<8 + lo
-
-	c.p |= InterruptDisableFlagMask
+	c.interrupt(true, false)
 }
 
-func (c *CPU) bvc(addr uint16) bool {
-	isBranched := c.p&OverflowFlagMask == 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) bvc(addr uint16) {
+	if c.p&OverflowFlagMask == 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
-func (c *CPU) bvs(addr uint16) bool {
-	isBranched := c.p&OverflowFlagMask != 0
-	if isBranched {
-		c.pc = addr
+func (c *CPU) bvs(addr uint16) {
+	if c.p&OverflowFlagMask != 0 {
+		c.branch(addr)
 	}
-	return isBranched
 }
 
 func (c *CPU) clc() {
@@ -284,8 +279,11 @@ func (c *CPU) clv() {
 }
 
 func (c *CPU) cmp(addr uint16) {
+	c.cmpValue(c.read(addr))
+}
+
+func (c *CPU) cmpValue(mem byte) {
 	a := c.a
-	mem := c.read(addr)
 	result := a - mem
 
 	if a >= mem {
@@ -350,12 +348,13 @@ func (c *CPU) cpy(addr uint16) {
 }
 
 func (c *CPU) dcp(addr uint16) {
-	c.dec(addr)
-	c.cmp(addr)
+	c.cmpValue(c.dec(addr))
 }
 
-func (c *CPU) dec(addr uint16) {
-	result := c.read(addr) - 1
+func (c *CPU) dec(addr uint16) byte {
+	val := c.read(addr)
+	c.write(addr, val) // Dummy write
+	result := val - 1
 
 	if result == 0 {
 		c.p |= ZeroFlagMask
@@ -368,6 +367,7 @@ func (c *CPU) dec(addr uint16) {
 		c.p &^= NegativeFlagMask
 	}
 	c.write(addr, result)
+	return result
 }
 
 func (c *CPU) dex() {
@@ -405,7 +405,11 @@ func (c *CPU) dey() {
 }
 
 func (c *CPU) eor(addr uint16) {
-	result := c.a ^ c.read(addr)
+	c.eorValue(c.read(addr))
+}
+
+func (c *CPU) eorValue(val byte) {
+	result := c.a ^ val
 
 	if result == 0 {
 		c.p |= ZeroFlagMask
@@ -421,8 +425,10 @@ func (c *CPU) eor(addr uint16) {
 	c.a = result
 }
 
-func (c *CPU) inc(addr uint16) {
-	result := c.read(addr) + 1
+func (c *CPU) inc(addr uint16) byte {
+	val := c.read(addr)
+	c.write(addr, val) // Dummy write
+	result := val + 1
 
 	if result == 0 {
 		c.p |= ZeroFlagMask
@@ -436,6 +442,7 @@ func (c *CPU) inc(addr uint16) {
 	}
 
 	c.write(addr, result)
+	return result
 }
 
 func (c *CPU) inx() {
@@ -473,8 +480,7 @@ func (c *CPU) iny() {
 }
 
 func (c *CPU) isc(addr uint16) {
-	c.inc(addr)
-	c.sbc(addr)
+	c.sbcValue(c.inc(addr))
 }
 
 func (c *CPU) jmp(addr uint16) {
@@ -482,6 +488,7 @@ func (c *CPU) jmp(addr uint16) {
 }
 
 func (c *CPU) jsr(addr uint16) {
+	c.dummyRead(0x0100 + uint16(c.s))
 	pushAddr := c.pc - 1
 	hi := byte(pushAddr >> 8)
 	lo := byte(pushAddr & 0x00FF)
@@ -504,7 +511,7 @@ func (c *CPU) las(addr uint16) {
 
 func (c *CPU) laxAddr(addr uint16) {
 	c.lda(addr)
-	c.ldx(addr)
+	c.tax()
 }
 
 func (c *CPU) laxImm(addr uint16) {
@@ -563,8 +570,9 @@ func (c *CPU) ldy(addr uint16) {
 	c.y = result
 }
 
-func (c *CPU) lsr(addr uint16) {
+func (c *CPU) lsr(addr uint16) byte {
 	val := c.read(addr)
+	c.write(addr, val) // Dummy write
 	result := val >> 1
 
 	if val&0x01 != 0 {
@@ -580,6 +588,7 @@ func (c *CPU) lsr(addr uint16) {
 	c.p &^= NegativeFlagMask
 
 	c.write(addr, result)
+	return result
 }
 
 func (c *CPU) lsrAccum() {
@@ -605,7 +614,11 @@ func (c *CPU) nop() {
 }
 
 func (c *CPU) ora(addr uint16) {
-	result := c.a | c.read(addr)
+	c.oraValue(c.read(addr))
+}
+
+func (c *CPU) oraValue(val byte) {
+	result := c.a | val
 
 	if result == 0 {
 		c.p |= ZeroFlagMask
@@ -632,6 +645,7 @@ func (c *CPU) php() {
 }
 
 func (c *CPU) pla() {
+	c.dummyRead(0x0100 + uint16(c.s))
 	c.s += 1
 	result := c.read(0x0100 + uint16(c.s))
 
@@ -650,21 +664,19 @@ func (c *CPU) pla() {
 }
 
 func (c *CPU) plp() {
+	c.dummyRead(0x0100 + uint16(c.s))
 	c.s += 1
 	result := c.read(0x0100+uint16(c.s)) & 0xCF
-	if result&0x04 != c.p&0x04 {
-		c.isIFlagToggleDelayed = true
-	}
-	c.p = c.p&0x34 | result&0xCB
+	c.p = c.p&0x30 | result
 }
 
 func (c *CPU) rla(addr uint16) {
-	c.rol(addr)
-	c.and(addr)
+	c.andValue(c.rol(addr))
 }
 
-func (c *CPU) rol(addr uint16) {
+func (c *CPU) rol(addr uint16) byte {
 	val := c.read(addr)
+	c.write(addr, val) // Dummy write
 	result := val<<1 + c.p&CarryFlagMask
 
 	if val&0x80 != 0 {
@@ -684,6 +696,7 @@ func (c *CPU) rol(addr uint16) {
 	}
 
 	c.write(addr, result)
+	return result
 }
 
 func (c *CPU) rolAccum() {
@@ -709,8 +722,9 @@ func (c *CPU) rolAccum() {
 	c.a = result
 }
 
-func (c *CPU) ror(addr uint16) {
+func (c *CPU) ror(addr uint16) byte {
 	val := c.read(addr)
+	c.write(addr, val) // Dummy write
 	carry := c.p & 0x01 << 7
 	result := carry + val>>1
 
@@ -731,6 +745,7 @@ func (c *CPU) ror(addr uint16) {
 	}
 
 	c.write(addr, result)
+	return result
 }
 
 func (c *CPU) rorAccum() {
@@ -758,11 +773,11 @@ func (c *CPU) rorAccum() {
 }
 
 func (c *CPU) rra(addr uint16) {
-	c.ror(addr)
-	c.adc(addr)
+	c.adcValue(c.ror(addr))
 }
 
 func (c *CPU) rti() {
+	c.dummyRead(0x0100 + uint16(c.s))
 	c.s += 1
 	status := c.read(0x0100+uint16(c.s)) & 0xCF
 	c.p = c.p&0x30 | status
@@ -777,13 +792,16 @@ func (c *CPU) rti() {
 }
 
 func (c *CPU) rts() {
+	c.dummyRead(0x0100 + uint16(c.s))
 	c.s += 1
 	lo := uint16(c.read(0x0100 + uint16(c.s)))
 
 	c.s += 1
 	hi := uint16(c.read(0x0100 + uint16(c.s)))
 
-	c.pc = hi<<8 + lo + 1
+	c.pc = hi<<8 + lo
+	c.dummyRead(c.pc)
+	c.pc++
 }
 
 func (c *CPU) sax(addr uint16) {
@@ -791,7 +809,10 @@ func (c *CPU) sax(addr uint16) {
 }
 
 func (c *CPU) sbc(addr uint16) {
-	mem := c.read(addr)
+	c.sbcValue(c.read(addr))
+}
+
+func (c *CPU) sbcValue(mem byte) {
 	var carryNot byte
 	if c.p&CarryFlagMask == 0 {
 		carryNot = 1
@@ -834,9 +855,7 @@ func (c *CPU) sed() {
 }
 
 func (c *CPU) sei() {
-	if c.p&InterruptDisableFlagMask == 0 {
-		c.isIFlagToggleDelayed = true
-	}
+	c.p |= InterruptDisableFlagMask
 }
 
 func (c *CPU) shx(addr uint16) {
@@ -850,13 +869,11 @@ func (c *CPU) shy(addr uint16) {
 }
 
 func (c *CPU) slo(addr uint16) {
-	c.asl(addr)
-	c.ora(addr)
+	c.oraValue(c.asl(addr))
 }
 
 func (c *CPU) sre(addr uint16) {
-	c.lsr(addr)
-	c.eor(addr)
+	c.eorValue(c.lsr(addr))
 }
 
 func (c *CPU) sta(addr uint16) {
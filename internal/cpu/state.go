@@ -0,0 +1,42 @@
+package cpu
+
+// State is a serializable snapshot of the CPU, used for save states.
+type State struct {
+	A, X, Y, S, P byte
+	PC            uint16
+	IsPanic       bool
+	IsNMILine     bool
+	IsNMIPolled   bool
+	IsIRQLine     bool
+	IsIRQPolled   bool
+}
+
+func (c *CPU) SaveState() State {
+	return State{
+		A:           c.a,
+		X:           c.x,
+		Y:           c.y,
+		S:           c.s,
+		P:           c.p,
+		PC:          c.pc,
+		IsPanic:     c.IsPanic,
+		IsNMILine:   c.isNMILine,
+		IsNMIPolled: c.isNMIPolled,
+		IsIRQLine:   c.isIRQLine,
+		IsIRQPolled: c.isIRQPolled,
+	}
+}
+
+func (c *CPU) LoadState(s State) {
+	c.a = s.A
+	c.x = s.X
+	c.y = s.Y
+	c.s = s.S
+	c.p = s.P
+	c.pc = s.PC
+	c.IsPanic = s.IsPanic
+	c.isNMILine = s.IsNMILine
+	c.isNMIPolled = s.IsNMIPolled
+	c.isIRQLine = s.IsIRQLine
+	c.isIRQPolled = s.IsIRQPolled
+}
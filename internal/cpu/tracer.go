@@ -26,7 +26,7 @@ func NewTracer(c *CPU) *Tracer {
 
 // The Record Saves the current CPU Registers state in a ring buffer.
 func (t *Tracer) Record(c *CPU) {
-	op := c.read(c.pc)
+	op := c.sys.Read(c.pc) // Peek without spending a cycle
 	var opName string
 	opName = opTable[op].Name
 	t.buf[t.index] = TraceEntry{
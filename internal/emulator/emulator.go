@@ -1,84 +1,213 @@
 package emulator
 
 import (
+	"hash/crc32"
+	"image"
 	"nesutaro/internal/cartridge"
 	"nesutaro/internal/cpu"
 	cbus "nesutaro/internal/cpu/bus"
 	"nesutaro/internal/joypad"
+	"nesutaro/internal/movie"
 	"nesutaro/internal/ppu"
 	pbus "nesutaro/internal/ppu/bus"
-
-	"github.com/hajimehoshi/ebiten/v2"
+	"nesutaro/internal/ppu/filter"
+	"nesutaro/internal/ppu/palette"
+	"nesutaro/internal/region"
 )
 
-const (
-	CyclesPerFrame float64 = 1789773 / 60.0
-)
+// Hotkeys are the emulator controls pressed since the previous frame.
+// The front-end sets them with SetHotkeys() before each RunFrame().
+type Hotkeys struct {
+	TogglePause bool // Toggle Run/Pause Mode
+	Step        bool // Run a single step while paused
+	Quit        bool
+}
 
 type Emulator struct {
 	CPU       *cpu.CPU
 	cpuCycles float64
 
+	Region         region.Region
+	cyclesPerFrame float64
+	isFrameStarted bool // The input devices were polled for the current frame
+	frameCount     int  // Frames since power-on
+	lagCount       int  // Frames in which the controllers were not strobed
+
 	IsPaused    bool
 	IsPauseMode bool
 
-	isKeyP       bool
-	isKeyS       bool
-	isKeyEsc     bool
-	isPrevKeyP   bool
-	isPrevKeyS   bool
-	isPrevKeyEsc bool
+	Palette *palette.Palette
+	Filter  *filter.Pipeline // nil = palette lookup only
+	screen  *image.RGBA
+
+	pads     [4]*joypad.Joypad // Standard controllers of players 1 ~ 4
+	multitap joypad.Multitap   // Set by ConnectControllers, cleared by Plug
+	hotkeys  Hotkeys
+	rom      []byte
+	romCRC   uint32 // Identifies the ROM in save states
+
+	// Input movie (movie.go)
+	movie     *movie.Movie
+	movieMode movieMode
+	moviePos  int // Next frame to play
+	// The devices plugged before PlayMovie. They are plugged back when it ends.
+	savedPorts    [2]joypad.Device
+	savedMultitap joypad.Multitap
 }
 
 func NewEmulator(rom /* , sav */ []byte) *Emulator {
-	cart := cartridge.NewCartridge(rom /* , sav */)
+	e := &Emulator{
+		Palette:     palette.Default(),
+		screen:      image.NewRGBA(image.Rect(0, 0, 256, 240)),
+		IsPauseMode: false,
+		IsPaused:    false,
+		rom:         rom,
+		romCRC:      crc32.ChecksumIEEE(rom),
+	}
+	e.Region, _ = region.Detect(rom, nil)
+	e.PowerOn()
+	for i := range e.pads {
+		e.pads[i] = joypad.NewJoypad()
+	}
+	e.ConnectControllers(joypad.NoMultitap)
+
+	return e
+}
+
+// PowerOn power-cycles the console: every component starts over from the ROM.
+// The region, the settings and the devices in the ports are kept, but the
+// devices are reset.
+func (e *Emulator) PowerOn() {
+	cart := cartridge.NewCartridge(e.rom /* , sav */)
 	pbus := pbus.NewBus(cart)
 	p := ppu.NewPPU(pbus)
-	j := joypad.NewJoypad()
-	cbus := cbus.NewBus(cart, p, j)
+	cbus := cbus.NewBus(cart, p)
 	c := cpu.NewCPU(cbus)
 	c.Tracer = cpu.NewTracer(c)
 
-	e := &Emulator{
-		CPU:         c,
-		IsPauseMode: false,
-		IsPaused:    false,
+	if e.CPU != nil {
+		cbus.Ports = e.CPU.Bus.Ports
+		p.SetIsSpriteLimitDisabled(e.CPU.Bus.PPU.IsSpriteLimitDisabled())
 	}
+	for _, d := range cbus.Ports {
+		if d != nil {
+			d.Reset()
+		}
+	}
+	// The controllers a multitap may plug later start over too.
+	for _, pad := range e.pads {
+		if pad != nil {
+			pad.Reset()
+		}
+	}
+	e.CPU = c
+	e.cpuCycles = 0
+	e.isFrameStarted = false
+	e.frameCount, e.lagCount = 0, 0
+	e.SetRegion(e.Region)
+}
 
-	return e
+// The region is detected from the ROM header by NewEmulator().
+// Set it again to use a ROM database or a user override.
+func (e *Emulator) SetRegion(r region.Region) {
+	e.Region = r
+	e.cyclesPerFrame = r.Timing().CyclesPerFrame()
+	e.CPU.Bus.SetRegion(r)
+	e.CPU.Bus.PPU.SetRegion(r)
+}
+
+// Get the last PPU frame converted from pixel indices to RGBA with e.Palette,
+// through e.Filter if set (the image is then larger than 256x240).
+// The image is reused by the next call.
+func (e *Emulator) GetGameScreen() *image.RGBA {
+	if e.Filter != nil {
+		return e.Filter.Apply(e.CPU.Bus.PPU.GetViewport()[:], e.Palette)
+	}
+	e.Palette.ToRGBA(e.screen, e.CPU.Bus.PPU.GetViewport()[:])
+	return e.screen
+}
+
+// Plug connects a device to a controller port (0 = $4016, 1 = $4017).
+// nil leaves the port empty.
+func (e *Emulator) Plug(port int, d joypad.Device) {
+	e.CPU.Bus.Ports[port] = d
+	e.multitap = joypad.NoMultitap
+}
+
+// ConnectControllers plugs the standard controllers into both ports through m.
+// Without a multitap, only players 1 and 2 are connected (the default).
+func (e *Emulator) ConnectControllers(m joypad.Multitap) {
+	e.CPU.Bus.Ports = m.Devices(e.pads)
+	e.multitap = m
+}
+
+// Joypad returns the standard controller of a player (0 ~ 3).
+// It exists even when it is not connected.
+func (e *Emulator) Joypad(player int) *joypad.Joypad {
+	return e.pads[player]
+}
+
+func (e *Emulator) SetHotkeys(h Hotkeys) {
+	e.hotkeys = h
 }
 
 func (e *Emulator) RunFrame() int {
-	maxCycles := CyclesPerFrame
-	e.CPU.Bus.Joypad.Update()
+	maxCycles := e.cyclesPerFrame
 	for e.cpuCycles < maxCycles {
-		e.updateEbitenKeys()
 		e.updateEmuMode()
-		if e.CPU.IsPanic || e.isKeyEsc { // for debug
+		isQuit := e.hotkeys.Quit
+		// Hotkeys act only once per frame.
+		e.hotkeys = Hotkeys{}
+		if e.CPU.IsPanic || isQuit { // for debug
 			e.panicDump()
 			return -1
 		} else if e.IsPaused {
 			return 0
 		}
+		if !e.isFrameStarted {
+			e.startFrame()
+		}
 		var c int
 		c = e.CPU.Step()
 		//e.CPU.Bus.Timer.Step(c, e.CPU.IsStopped)
-		e.CPU.Bus.PPU.Step(c)
 		//e.CPU.Bus.APU.Step(c / cpuSpeed)
 		e.CPU.Tracer.Record(e.CPU)
 		e.cpuCycles += float64(c)
 	}
 	e.cpuCycles -= maxCycles
+	e.isFrameStarted = false
+	e.frameCount++
+	if !e.CPU.Bus.IsStrobed() {
+		e.lagCount++
+	}
 	return 0
 }
 
-// KeyP: Toggle Run/Pause Mode
-// KeyS: Run a single step
+// startFrame polls the input devices once per emulated frame,
+// so that paused or stepped frames don't advance them.
+// A movie being played overrides the polled input.
+func (e *Emulator) startFrame() {
+	for _, d := range e.CPU.Bus.Ports {
+		if d != nil {
+			d.Update()
+		}
+	}
+	e.updateMovie()
+	e.isFrameStarted = true
+	e.CPU.Bus.ClearStrobed()
+}
+
+// FrameCounters returns the frames emulated since power-on and
+// how many of them were lag frames (the game did not strobe the controllers).
+func (e *Emulator) FrameCounters() (frames, lag int) {
+	return e.frameCount, e.lagCount
+}
+
 func (e *Emulator) updateEmuMode() {
-	if e.isKeyP {
+	if e.hotkeys.TogglePause {
 		e.IsPauseMode = !e.IsPauseMode
 	}
-	e.IsPaused = e.IsPauseMode && !e.isKeyS
+	e.IsPaused = e.IsPauseMode && !e.hotkeys.Step
 }
 
 // In case of Panic, CPU status is output to the console.
@@ -86,18 +215,6 @@ func (e *Emulator) panicDump() {
 	e.CPU.Tracer.Dump()
 }
 
-func (e *Emulator) updateEbitenKeys() {
-	isP := ebiten.IsKeyPressed(ebiten.KeyP)
-	isS := ebiten.IsKeyPressed(ebiten.KeyS)
-	isEsc := ebiten.IsKeyPressed(ebiten.KeyEscape)
-	e.isKeyP = !e.isPrevKeyP && isP
-	e.isKeyS = !e.isPrevKeyS && isS
-	e.isKeyEsc = !e.isPrevKeyEsc && isEsc
-	e.isPrevKeyP = isP
-	e.isPrevKeyS = isS
-	e.isPrevKeyEsc = isEsc
-}
-
 func (e *Emulator) GetDebugLog() []string {
 	var state string
 	if e.IsPaused {
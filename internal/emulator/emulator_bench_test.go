@@ -0,0 +1,33 @@
+package emulator
+
+import "testing"
+
+// benchROM turns on rendering and NMIs, then loops. Its CHR ROM is filled
+// so that the tiles are not blank.
+func benchROM() []byte {
+	chr := make([]byte, 0x2000)
+	for i := range chr {
+		chr[i] = byte(i * 37)
+	}
+	return newTestROM(nmiLoop(0x1E), []byte{0x40}, chr) // RTI
+}
+
+// Each op is one frame.
+func BenchmarkRunFrame(b *testing.B) {
+	e := NewEmulator(benchROM())
+	b.ReportAllocs()
+	for b.Loop() {
+		if e.RunFrame() == -1 {
+			b.Fatal("CPU panic")
+		}
+	}
+}
+
+func BenchmarkGetGameScreen(b *testing.B) {
+	e := NewEmulator(benchROM())
+	e.RunFrame()
+	b.ReportAllocs()
+	for b.Loop() {
+		e.GetGameScreen()
+	}
+}
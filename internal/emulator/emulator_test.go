@@ -0,0 +1,67 @@
+package emulator
+
+import (
+	"nesutaro/internal/joypad"
+	"reflect"
+	"testing"
+)
+
+// newTestROM builds an NROM image running reset from $8000 and nmi from
+// $8100, with chr as its CHR ROM (blank if nil). IRQs return at once.
+func newTestROM(reset, nmi, chr []byte) []byte {
+	rom := make([]byte, 0x10+0x4000+0x2000)
+	copy(rom, "NES\x1a\x01\x01")
+	prg := rom[0x10 : 0x10+0x4000]
+	copy(prg, reset)
+	copy(prg[0x100:], nmi)
+	prg[0x200] = 0x40                                              // RTI
+	copy(prg[0x3FFA:], []byte{0x00, 0x81, 0x00, 0x80, 0x00, 0x82}) // NMI, RESET, IRQ
+	copy(rom[0x10+0x4000:], chr)
+	return rom
+}
+
+// nmiLoop turns on NMIs (and the rendering set by mask), then loops.
+func nmiLoop(mask byte) []byte {
+	return []byte{
+		0xA9, mask, // LDA #mask
+		0x8D, 0x01, 0x20, // STA $2001
+		0xA9, 0x80, // LDA #$80
+		0x8D, 0x00, 0x20, // STA $2000
+		0x4C, 0x0A, 0x80, // JMP $800A
+	}
+}
+
+func TestPowerOnResetsDevices(t *testing.T) {
+	const buttonA = 0x01
+	e := NewEmulator(newTestROM(nmiLoop(0x00), []byte{0x40}, nil)) // RTI
+	e.ConnectControllers(joypad.FourScore)
+	pad := e.Joypad(0)
+	pad.SetTurboInputs(buttonA)
+	pad.SetTurboPeriod(4)
+	e.RunFrame()
+	e.RunFrame()
+	e.RunFrame()
+	// Strobe and read 3 bits as a game does.
+	e.CPU.Bus.Write(0x4016, 1)
+	pad.SetInputs(0xFF)
+	e.CPU.Bus.Write(0x4016, 0)
+	for i := 0; i < 3; i++ {
+		e.CPU.Bus.Read(0x4016)
+	}
+	pad.SetInputs(0)
+
+	e.PowerOn()
+	for i, d := range e.CPU.Bus.Ports {
+		s := d.SaveState().(joypad.FourScoreState)
+		if !reflect.DeepEqual(s, joypad.FourScoreState{}) {
+			t.Errorf("port %d after PowerOn: %+v", i, s)
+		}
+	}
+	// The turbo is pressed on the first half of its cycle.
+	for frame, want := range []byte{buttonA, buttonA, 0, 0, buttonA} {
+		if got := pad.Buttons(); got != want {
+			t.Errorf("turbo frame %d: buttons = %02X, want %02X", frame, got, want)
+		}
+		pad.Update()
+	}
+}
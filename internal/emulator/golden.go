@@ -0,0 +1,217 @@
+package emulator
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/joypad"
+	"nesutaro/internal/ppu/filter"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// InputScript is a scripted input for headless runs.
+// Each entry sets the button state (or the Zapper aim) from its frame onward,
+// until the next entry of the same kind replaces it.
+type InputScript []InputEvent
+
+type InputEvent struct {
+	Frame   int
+	Buttons byte // bit 0 = A ... bit 7 = RIGHT
+
+	// Zapper events aim the Zapper in port 2 instead.
+	IsZapper  bool
+	X, Y      int // -1 = off the screen
+	IsTrigger bool
+}
+
+// ParseInputScript reads an input script.
+// One event per line: "<frame> <buttons>", e.g. "120 START" or "300 A+RIGHT".
+// "-" releases all buttons.
+// Zapper events are "<frame> zapper <x>,<y>", with " fire" to pull the trigger,
+// or "<frame> zapper -" to aim off the screen.
+// Empty lines and lines starting with '#' are ignored.
+func ParseInputScript(r io.Reader) (InputScript, error) {
+	var script InputScript
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		isZapper := len(fields) >= 2 && strings.EqualFold(fields[1], "zapper")
+		if !isZapper && len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want \"<frame> <buttons>\"", n)
+		}
+		frame, err := strconv.Atoi(fields[0])
+		if err != nil || frame < 0 {
+			return nil, fmt.Errorf("line %d: bad frame %q", n, fields[0])
+		}
+		if len(script) > 0 && frame < script[len(script)-1].Frame {
+			return nil, fmt.Errorf("line %d: frames must be in ascending order", n)
+		}
+		if isZapper {
+			ev, err := parseZapper(fields[2:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			ev.Frame = frame
+			script = append(script, ev)
+			continue
+		}
+		buttons, err := joypad.ParseButtons(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		script = append(script, InputEvent{Frame: frame, Buttons: buttons})
+	}
+	return script, sc.Err()
+}
+
+// parseZapper reads the arguments of a Zapper event: "<x>,<y> [fire]" or "-".
+func parseZapper(args []string) (InputEvent, error) {
+	ev := InputEvent{IsZapper: true, X: -1, Y: -1}
+	switch {
+	case len(args) == 1 && args[0] == "-":
+		return ev, nil
+	case len(args) == 2 && strings.EqualFold(args[1], "fire"):
+		ev.IsTrigger = true
+	case len(args) != 1:
+		return ev, fmt.Errorf("want \"zapper <x>,<y> [fire]\" or \"zapper -\"")
+	}
+	xy := strings.Split(args[0], ",")
+	if len(xy) != 2 {
+		return ev, fmt.Errorf("bad zapper position %q", args[0])
+	}
+	var errX, errY error
+	ev.X, errX = strconv.Atoi(xy[0])
+	ev.Y, errY = strconv.Atoi(xy[1])
+	if errX != nil || errY != nil {
+		return ev, fmt.Errorf("bad zapper position %q", args[0])
+	}
+	return ev, nil
+}
+
+// ButtonsAt returns the button state the script holds at the given frame.
+func (s InputScript) ButtonsAt(frame int) byte {
+	var buttons byte
+	for _, ev := range s {
+		if ev.Frame > frame {
+			break
+		}
+		if !ev.IsZapper {
+			buttons = ev.Buttons
+		}
+	}
+	return buttons
+}
+
+// ZapperAt returns the Zapper aim and trigger the script holds at the given frame.
+func (s InputScript) ZapperAt(frame int) (x, y int, isTrigger bool) {
+	x, y = -1, -1
+	for _, ev := range s {
+		if ev.Frame > frame {
+			break
+		}
+		if ev.IsZapper {
+			x, y, isTrigger = ev.X, ev.Y, ev.IsTrigger
+		}
+	}
+	return x, y, isTrigger
+}
+
+// HasZapper reports whether the script uses the Zapper.
+func (s InputScript) HasZapper() bool {
+	for _, ev := range s {
+		if ev.IsZapper {
+			return true
+		}
+	}
+	return false
+}
+
+// RunHeadless runs the ROM for the given number of frames without a front-end,
+// feeding the scripted input, and returns a copy of the last game screen
+// (through the filter if not nil). A Zapper is plugged into port 2 if the script uses it.
+// A ROM the emulator cannot load is an error.
+func RunHeadless(rom []byte, frames int, script InputScript, f *filter.Pipeline) (*image.RGBA, error) {
+	if err := cartridge.CheckROM(rom); err != nil {
+		return nil, err
+	}
+	e := NewEmulator(rom)
+	e.Filter = f
+	var zapper *joypad.Zapper
+	if script.HasZapper() {
+		zapper = e.NewZapper()
+		e.Plug(1, zapper)
+	}
+	for i := 0; i < frames; i++ {
+		e.Joypad(0).SetInputs(script.ButtonsAt(i))
+		if zapper != nil {
+			zapper.SetInputs(script.ZapperAt(i))
+		}
+		if e.RunFrame() == -1 {
+			return nil, fmt.Errorf("CPU panic at frame %d", i)
+		}
+	}
+	src := e.GetGameScreen()
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	return dst, nil
+}
+
+// CompareFrames compares a rendered frame against a reference.
+// It returns the number of differing pixels and a diff image in which
+// matching pixels are a dimmed copy of the reference and differing ones are red.
+func CompareFrames(got, want image.Image) (int, *image.RGBA) {
+	b := want.Bounds()
+	diff := image.NewRGBA(b)
+	if got.Bounds() != b {
+		draw.Draw(diff, b, &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+		return b.Dx() * b.Dy(), diff
+	}
+	mismatches := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			gr, gg, gb, _ := got.At(x, y).RGBA()
+			wr, wg, wb, _ := want.At(x, y).RGBA()
+			if gr != wr || gg != wg || gb != wb {
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				mismatches++
+			} else {
+				diff.SetRGBA(x, y, color.RGBA{byte(wr >> 10), byte(wg >> 10), byte(wb >> 10), 255})
+			}
+		}
+	}
+	return mismatches, diff
+}
+
+// LoadPNG reads a PNG image.
+func LoadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// SavePNG writes the image as PNG, replacing any existing file.
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,95 @@
+package emulator
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// goldenFrames is the default of "nesutaro golden".
+const goldenFrames = 300
+
+// TestGolden renders the ROMs in testdata and compares them with their
+// goldens, in the layout of "nesutaro golden" (which records them):
+//
+//	testdata/<name>.nes, testdata/<name>.input (optional), testdata/golden/<name>.png
+//
+// On a mismatch, the diff is written to testdata/golden/<name>.diff.png.
+//
+// The ROMs are not part of the repository, so the test is skipped without them.
+func TestGolden(t *testing.T) {
+	roms, _ := filepath.Glob(filepath.Join("testdata", "*.nes"))
+	if len(roms) == 0 {
+		t.Skip("no ROMs in testdata")
+	}
+	for _, romPath := range roms {
+		name := strings.TrimSuffix(filepath.Base(romPath), ".nes")
+		t.Run(name, func(t *testing.T) {
+			rom, err := os.ReadFile(romPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var script InputScript
+			if f, err := os.Open(filepath.Join("testdata", name+".input")); err == nil {
+				script, err = ParseInputScript(f)
+				f.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := LoadPNG(filepath.Join("testdata", "golden", name+".png"))
+			if err != nil {
+				t.Skipf("no golden: %v", err)
+			}
+			got, err := RunHeadless(rom, goldenFrames, script, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diffPath := filepath.Join("testdata", "golden", name+".diff.png")
+			n, diff := CompareFrames(got, want)
+			if n == 0 {
+				os.Remove(diffPath)
+				return
+			}
+			if err := SavePNG(diffPath, diff); err != nil {
+				t.Fatal(err)
+			}
+			t.Errorf("%d pixels differ (see %s)", n, diffPath)
+		})
+	}
+}
+
+func TestRunHeadlessBadROM(t *testing.T) {
+	for name, rom := range map[string][]byte{
+		"empty":     nil,
+		"truncated": []byte("NES\x1a\x02\x01\x00\x00"),
+		"mapper":    append([]byte("NES\x1a\x01\x01\xF0\x00"), make([]byte, 8+0x4000+0x2000)...),
+	} {
+		if _, err := RunHeadless(rom, 1, nil, nil); err == nil {
+			t.Errorf("%s: RunHeadless() succeeded", name)
+		}
+	}
+}
+
+func TestCompareFrames(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if n, _ := CompareFrames(a, b); n != 0 {
+		t.Errorf("equal frames: %d pixels differ", n)
+	}
+	b.Set(1, 2, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+	b.Set(3, 3, color.RGBA{0x00, 0x10, 0x00, 0xFF})
+	n, diff := CompareFrames(a, b)
+	if n != 2 {
+		t.Errorf("%d pixels differ, want 2", n)
+	}
+	if c := diff.RGBAAt(1, 2); c.R != 0xFF || c.G != 0 || c.B != 0 {
+		t.Errorf("differing pixel is %v in the diff, want red", c)
+	}
+	if n, _ := CompareFrames(a, image.NewRGBA(image.Rect(0, 0, 4, 5))); n == 0 {
+		t.Error("frames of different sizes match")
+	}
+}
@@ -0,0 +1,206 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"nesutaro/internal/joypad"
+	"nesutaro/internal/movie"
+	"nesutaro/internal/region"
+)
+
+type movieMode int
+
+const (
+	movieOff movieMode = iota
+	movieRecording
+	moviePlaying
+)
+
+// MovieStatus describes the movie being recorded or played.
+type MovieStatus struct {
+	IsRecording bool
+	IsPlaying   bool
+	Frame       int // Frames recorded, or the next frame to play
+	Length      int
+}
+
+func (e *Emulator) MovieStatus() MovieStatus {
+	s := MovieStatus{
+		IsRecording: e.movieMode == movieRecording,
+		IsPlaying:   e.movieMode == moviePlaying,
+	}
+	if e.movie != nil {
+		s.Frame, s.Length = e.moviePos, len(e.movie.Frames)
+	}
+	if s.IsRecording {
+		s.Frame = s.Length
+	}
+	return s
+}
+
+// RecordMovie starts recording the input of every frame into a new movie,
+// from power-on (the console is power-cycled) or from the current state.
+// Only standard controllers, a multitap and Zappers can be recorded.
+func (e *Emulator) RecordMovie(isFromPowerOn bool) error {
+	m := movie.New()
+	m.ROMChecksum = movie.Checksum(e.rom)
+	m.IsPAL = e.Region == region.PAL
+	m.IsFourScore = e.multitap != joypad.NoMultitap
+	if !m.IsFourScore {
+		for i, d := range e.CPU.Bus.Ports {
+			switch d.(type) {
+			case nil:
+				m.Ports[i] = movie.PortNone
+			case *joypad.Joypad:
+				m.Ports[i] = movie.PortGamepad
+			case *joypad.Zapper:
+				m.Ports[i] = movie.PortZapper
+			default:
+				return fmt.Errorf("movies can't record the device in port %d", i+1)
+			}
+		}
+	}
+	if isFromPowerOn {
+		e.PowerOn()
+	} else {
+		var err error
+		if m.SaveState, err = e.SaveState(); err != nil {
+			return err
+		}
+	}
+	e.movie, e.movieMode, e.moviePos = m, movieRecording, 0
+	// In the middle of a frame (while paused), the input of the rest of
+	// the frame is the first one recorded.
+	if e.isFrameStarted {
+		e.updateMovie()
+	}
+	return nil
+}
+
+// PlayMovie plugs the devices of the movie, goes to its start (power-on or its
+// save state) and plays its input over the live input until its last frame.
+// The devices plugged before are plugged back when the movie ends or stops.
+func (e *Emulator) PlayMovie(m *movie.Movie) error {
+	if m.ROMChecksum != [16]byte{} && m.ROMChecksum != movie.Checksum(e.rom) {
+		return errors.New("movie was recorded with another ROM")
+	}
+	if m.IsPAL != (e.Region == region.PAL) {
+		return fmt.Errorf("movie does not match the region (running in %s)", e.Region)
+	}
+	if e.movieMode != moviePlaying {
+		e.savedPorts, e.savedMultitap = e.CPU.Bus.Ports, e.multitap
+	}
+	if m.IsFourScore {
+		// FM2 has no Famicom adapter. Keep it if it is connected.
+		if e.savedMultitap != joypad.Hori && e.savedMultitap != joypad.HoriFourPlayer {
+			e.ConnectControllers(joypad.FourScore)
+		}
+	} else {
+		for i, t := range m.Ports {
+			switch t {
+			case movie.PortNone:
+				e.Plug(i, nil)
+			case movie.PortGamepad:
+				e.Plug(i, e.pads[i])
+			case movie.PortZapper:
+				e.Plug(i, e.movieZapper(m, i))
+			}
+		}
+	}
+	if m.SaveState != nil {
+		if err := e.LoadState(m.SaveState); err != nil {
+			// A movie that was playing ends too, as its devices are unplugged.
+			e.restorePorts()
+			if e.movieMode == moviePlaying {
+				e.movie, e.movieMode, e.moviePos = nil, movieOff, 0
+			}
+			return fmt.Errorf("movie save state: %w", err)
+		}
+	} else {
+		e.PowerOn()
+	}
+	e.movie, e.movieMode, e.moviePos = m, moviePlaying, 0
+	// A state made in the middle of a frame plays its first input at once.
+	if e.isFrameStarted {
+		e.updateMovie()
+	}
+	return nil
+}
+
+// movieZapper returns the Zapper to plug into a port for the movie m:
+// the one plugged before (into either port, so that it keeps its source),
+// or a new one.
+func (e *Emulator) movieZapper(m *movie.Movie, port int) *joypad.Zapper {
+	if z, ok := e.savedPorts[port].(*joypad.Zapper); ok {
+		return z
+	}
+	if z, ok := e.savedPorts[port^1].(*joypad.Zapper); ok && m.Ports[port^1] != movie.PortZapper {
+		return z
+	}
+	return e.NewZapper()
+}
+
+// restorePorts plugs back the devices saved by PlayMovie.
+func (e *Emulator) restorePorts() {
+	e.CPU.Bus.Ports, e.multitap = e.savedPorts, e.savedMultitap
+	e.savedPorts = [2]joypad.Device{}
+}
+
+// StopMovie ends the recording or the playback and returns the movie.
+func (e *Emulator) StopMovie() *movie.Movie {
+	m := e.movie
+	if e.movieMode == moviePlaying {
+		e.restorePorts()
+	}
+	e.movie, e.movieMode, e.moviePos = nil, movieOff, 0
+	return m
+}
+
+// updateMovie plays or records the input of the frame that starts.
+// Soft resets are not emulated, so their command is ignored.
+func (e *Emulator) updateMovie() {
+	switch e.movieMode {
+	case moviePlaying:
+		if e.moviePos >= len(e.movie.Frames) {
+			e.restorePorts()
+			e.movieMode = movieOff
+			return
+		}
+		f := e.movie.Frames[e.moviePos]
+		e.moviePos++
+		if f.Commands&movie.CommandPower != 0 {
+			e.PowerOn()
+		}
+		if e.movie.IsFourScore {
+			for i, pad := range e.pads {
+				pad.Override(f.Pads[i])
+			}
+			return
+		}
+		for i, d := range e.CPU.Bus.Ports {
+			switch d := d.(type) {
+			case *joypad.Joypad:
+				d.Override(f.Pads[i])
+			case *joypad.Zapper:
+				d.SetInputs(f.Zappers[i].X, f.Zappers[i].Y, f.Zappers[i].IsTrigger)
+			}
+		}
+
+	case movieRecording:
+		var f movie.Frame
+		if e.movie.IsFourScore {
+			for i, pad := range e.pads {
+				f.Pads[i] = pad.Buttons()
+			}
+		}
+		for i, d := range e.CPU.Bus.Ports {
+			switch d := d.(type) {
+			case *joypad.Joypad:
+				f.Pads[i] = d.Buttons()
+			case *joypad.Zapper:
+				f.Zappers[i].X, f.Zappers[i].Y, f.Zappers[i].IsTrigger = d.Inputs()
+			}
+		}
+		e.movie.Frames = append(e.movie.Frames, f)
+	}
+}
@@ -0,0 +1,169 @@
+package emulator
+
+import (
+	"bytes"
+	"nesutaro/internal/joypad"
+	"nesutaro/internal/movie"
+	"testing"
+)
+
+func newMovieEmulator() *Emulator {
+	return NewEmulator(newTestROM(nmiLoop(0x00), []byte{0x40}, nil)) // RTI
+}
+
+func newTestMovie(e *Emulator, ports [2]movie.PortType, frames int) *movie.Movie {
+	m := movie.New()
+	m.ROMChecksum = movie.Checksum(e.rom)
+	m.Ports = ports
+	m.Frames = make([]movie.Frame, frames)
+	return m
+}
+
+// The devices plugged before a movie are plugged back when it ends,
+// when it is stopped and when it fails to start.
+func TestPlayMovieRestoresPorts(t *testing.T) {
+	e := newMovieEmulator()
+	z := e.NewZapper()
+	e.Plug(1, z)
+	before := e.CPU.Bus.Ports
+
+	m := newTestMovie(e, [2]movie.PortType{movie.PortZapper, movie.PortNone}, 3)
+	if err := e.PlayMovie(m); err != nil {
+		t.Fatal(err)
+	}
+	if e.CPU.Bus.Ports[0] != z || e.CPU.Bus.Ports[1] != nil {
+		t.Errorf("ports while playing = %v, want the Zapper in port 1", e.CPU.Bus.Ports)
+	}
+	for i := 0; i < 3; i++ {
+		e.RunFrame()
+	}
+	if !e.MovieStatus().IsPlaying {
+		t.Fatal("the movie ended early")
+	}
+	e.RunFrame()
+	if e.MovieStatus().IsPlaying || e.CPU.Bus.Ports != before {
+		t.Errorf("ports after the movie = %v, want %v", e.CPU.Bus.Ports, before)
+	}
+
+	e.ConnectControllers(joypad.Hori)
+	before = e.CPU.Bus.Ports
+	if err := e.PlayMovie(newTestMovie(e, [2]movie.PortType{movie.PortGamepad, movie.PortGamepad}, 10)); err != nil {
+		t.Fatal(err)
+	}
+	e.RunFrame()
+	e.StopMovie()
+	if e.CPU.Bus.Ports != before || e.multitap != joypad.Hori {
+		t.Errorf("ports after StopMovie = %v (%v), want the Hori adapter", e.CPU.Bus.Ports, e.multitap)
+	}
+
+	bad := newTestMovie(e, [2]movie.PortType{movie.PortZapper, movie.PortZapper}, 10)
+	bad.SaveState = []byte("not a state")
+	if err := e.PlayMovie(bad); err == nil {
+		t.Fatal("PlayMovie with a bad save state: no error")
+	}
+	if e.CPU.Bus.Ports != before || e.multitap != joypad.Hori {
+		t.Errorf("ports after a failed PlayMovie = %v (%v), want the Hori adapter", e.CPU.Bus.Ports, e.multitap)
+	}
+}
+
+// inputNMI reads controller 1 and shows its buttons as the backdrop color.
+var inputNMI = []byte{
+	0xA9, 0x01, // LDA #$01
+	0x8D, 0x16, 0x40, // STA $4016
+	0xA9, 0x00, // LDA #$00
+	0x8D, 0x16, 0x40, // STA $4016
+	0xA2, 0x08, // LDX #$08
+	0xAD, 0x16, 0x40, // LDA $4016
+	0x4A,       // LSR A
+	0x26, 0x12, // ROL $12
+	0xCA,       // DEX
+	0xD0, 0xF7, // BNE -9
+	0xA9, 0x3F, // LDA #$3F
+	0x8D, 0x06, 0x20, // STA $2006
+	0xA9, 0x00, // LDA #$00
+	0x8D, 0x06, 0x20, // STA $2006
+	0xA5, 0x12, // LDA $12
+	0x29, 0x3F, // AND #$3F
+	0x8D, 0x07, 0x20, // STA $2007
+	0xA9, 0x00, // LDA #$00
+	0x8D, 0x06, 0x20, // STA $2006
+	0x8D, 0x06, 0x20, // STA $2006
+	0x40, // RTI
+}
+
+// The starts of the movies of TestRecordThenPlay
+const (
+	fromPowerOn = iota
+	fromState
+	fromMidFrame // A state made while paused in the middle of a frame
+)
+
+// A recorded movie, written and read back as FM2, plays the same frames
+// whatever the live input is.
+func TestRecordThenPlay(t *testing.T) {
+	rom := newTestROM(nmiLoop(0x00), inputNMI, nil)
+	for _, start := range []int{fromPowerOn, fromState, fromMidFrame} {
+		e := NewEmulator(rom)
+		for i := 0; i < 10; i++ {
+			e.Joypad(0).SetInputs(byte(i))
+			e.RunFrame()
+		}
+		if start == fromMidFrame {
+			e.SetHotkeys(Hotkeys{TogglePause: true})
+			e.RunFrame()
+			for i := 0; i < 1000; i++ {
+				e.SetHotkeys(Hotkeys{Step: true})
+				e.RunFrame()
+			}
+			e.SetHotkeys(Hotkeys{TogglePause: true})
+		}
+		if err := e.RecordMovie(start == fromPowerOn); err != nil {
+			t.Fatal(err)
+		}
+		var want [][256 * 240]uint16
+		for i := 0; i < 60; i++ {
+			// The host input changes only between frames.
+			if i > 0 || start != fromMidFrame {
+				e.Joypad(0).SetInputs(byte(i*37) ^ byte(i>>2))
+			}
+			e.RunFrame()
+			want = append(want, *e.CPU.Bus.PPU.GetViewport())
+		}
+		wantFrames, wantLags := e.FrameCounters()
+
+		var buf bytes.Buffer
+		if err := e.StopMovie().Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		m, err := movie.Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (m.SaveState == nil) != (start == fromPowerOn) || len(m.Frames) != len(want) {
+			t.Fatalf("start %d: movie has %d frames, save state %v", start, len(m.Frames), m.SaveState != nil)
+		}
+
+		e = NewEmulator(rom)
+		for i := 0; i < 5; i++ {
+			e.Joypad(0).SetInputs(0x55)
+			e.RunFrame()
+		}
+		if err := e.PlayMovie(m); err != nil {
+			t.Fatal(err)
+		}
+		// A mid-frame state finishes its frame with the first input.
+		if start == fromMidFrame && (!e.isFrameStarted || e.MovieStatus().Frame != 1) {
+			t.Errorf("mid-frame state: frame started %v, movie at frame %d", e.isFrameStarted, e.MovieStatus().Frame)
+		}
+		for i := range want {
+			e.Joypad(0).SetInputs(0xFF) // Overridden by the movie
+			e.RunFrame()
+			if *e.CPU.Bus.PPU.GetViewport() != want[i] {
+				t.Fatalf("start %d: frame %d differs", start, i)
+			}
+		}
+		if frames, lags := e.FrameCounters(); frames != wantFrames || lags != wantLags {
+			t.Errorf("start %d: counters = %d, %d, want %d, %d", start, frames, lags, wantFrames, wantLags)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"nesutaro/internal/apu"
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/cpu"
+	cbus "nesutaro/internal/cpu/bus"
+	"nesutaro/internal/joypad"
+	"nesutaro/internal/ppu"
+	pbus "nesutaro/internal/ppu/bus"
+	"nesutaro/internal/region"
+	"reflect"
+)
+
+// Bump stateVersion whenever a component State changes incompatibly.
+const stateVersion = 19
+
+type state struct {
+	Version   int
+	ROMCRC    uint32
+	Region    region.Region
+	CPUCycles float64
+	Frames    int // Frame counters
+	LagFrames int
+
+	// The input devices were polled for the current frame. A state made in
+	// the middle of a frame (while paused) finishes it without polling them,
+	// so a movie recorded from it starts on the same frame.
+	IsFrameStarted bool
+
+	CPU    cpu.State
+	CPUBus cbus.State
+	PPU    ppu.State
+	PPUBus pbus.State
+	APU    apu.State
+	Cart   cartridge.MapperState
+	Ports  [2]joypad.DeviceState // nil = nothing plugged
+}
+
+// SaveState serializes the whole machine state.
+func (e *Emulator) SaveState() ([]byte, error) {
+	s := e.snapshot()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadState restores a state made by SaveState() for the same ROM.
+// The current state is kept on error.
+func (e *Emulator) LoadState(data []byte) error {
+	var s state
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != stateVersion {
+		return fmt.Errorf("save state version %d is not supported", s.Version)
+	}
+	if s.ROMCRC != e.romCRC {
+		return errors.New("save state belongs to another ROM")
+	}
+	if s.Region != e.Region {
+		return fmt.Errorf("save state was made in %s mode (running in %s)", s.Region, e.Region)
+	}
+	for i, d := range e.CPU.Bus.Ports {
+		var current joypad.DeviceState
+		if d != nil {
+			current = d.SaveState()
+		}
+		if reflect.TypeOf(current) != reflect.TypeOf(s.Ports[i]) {
+			return fmt.Errorf("save state has another device in port %d", i+1)
+		}
+	}
+	// A component rejects values out of its range, after the others may have
+	// been restored: put the machine back as it was.
+	backup := e.snapshot()
+	if err := e.restore(s); err != nil {
+		if err := e.restore(backup); err != nil {
+			panic(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Emulator) snapshot() state {
+	s := state{
+		Version:   stateVersion,
+		ROMCRC:    e.romCRC,
+		Region:    e.Region,
+		CPUCycles: e.cpuCycles,
+		Frames:    e.frameCount,
+		LagFrames: e.lagCount,
+
+		IsFrameStarted: e.isFrameStarted,
+
+		CPU:    e.CPU.SaveState(),
+		CPUBus: e.CPU.Bus.SaveState(),
+		PPU:    e.CPU.Bus.PPU.SaveState(),
+		PPUBus: e.CPU.Bus.PPU.Bus.SaveState(),
+		APU:    e.CPU.Bus.APU.SaveState(),
+		Cart:   e.CPU.Bus.Cart.SaveState(),
+	}
+	for i, d := range e.CPU.Bus.Ports {
+		if d != nil {
+			s.Ports[i] = d.SaveState()
+		}
+	}
+	return s
+}
+
+func (e *Emulator) restore(s state) error {
+	if err := e.CPU.Bus.Cart.LoadState(s.Cart); err != nil {
+		return err
+	}
+	if err := e.CPU.Bus.PPU.LoadState(s.PPU); err != nil {
+		return err
+	}
+	if err := e.CPU.Bus.APU.LoadState(s.APU); err != nil {
+		return err
+	}
+	for i, d := range e.CPU.Bus.Ports {
+		if d == nil {
+			continue
+		}
+		if err := d.LoadState(s.Ports[i]); err != nil {
+			return fmt.Errorf("port %d: %w", i+1, err)
+		}
+	}
+	e.cpuCycles = s.CPUCycles
+	e.frameCount, e.lagCount = s.Frames, s.LagFrames
+	e.isFrameStarted = s.IsFrameStarted
+	e.CPU.LoadState(s.CPU)
+	e.CPU.Bus.LoadState(s.CPUBus)
+	e.CPU.Bus.PPU.Bus.LoadState(s.PPUBus)
+	return nil
+}
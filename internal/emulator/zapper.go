@@ -0,0 +1,47 @@
+package emulator
+
+import "nesutaro/internal/joypad"
+
+// The Zapper photodiode sees an area of a few pixels around the aim.
+// CRT phosphors glow only for a short while after the beam lit them,
+// so a pixel is seen if it was drawn within the last zapperGlowLines lines.
+const (
+	zapperRadius     = 2
+	zapperGlowLines  = 20
+	zapperBrightness = 3 * 0x55 // Minimum R+G+B of a lit pixel
+)
+
+// NewZapper creates a Zapper whose light sensor watches this emulator's screen.
+// Plug it with Plug().
+func (e *Emulator) NewZapper() *joypad.Zapper {
+	return joypad.NewZapper(e.senseLight)
+}
+
+func (e *Emulator) senseLight(x, y int) bool {
+	if x < 0 || x >= 256 || y < 0 || y >= 240 {
+		return false
+	}
+	ppu := e.CPU.Bus.PPU
+	dot, line := ppu.GetBeam()
+	frame := ppu.GetBackViewport()
+	// The buffers are swapped at dot 1 of the VBlank line. From then on,
+	// the frame the beam has drawn is the front one.
+	if vblank := e.Region.Timing().VBlankLine; line > vblank || line == vblank && dot > 1 {
+		frame = ppu.GetViewport()
+	}
+	for py := max(y-zapperRadius, 0); py <= min(y+zapperRadius, 239); py++ {
+		if line < py || line-py > zapperGlowLines {
+			continue // Not drawn yet in this frame, or faded
+		}
+		for px := max(x-zapperRadius, 0); px <= min(x+zapperRadius, 255); px++ {
+			if line == py && dot <= px+1 {
+				break
+			}
+			c := e.Palette[frame[py*256+px]&0x1FF]
+			if int(c.R)+int(c.G)+int(c.B) >= zapperBrightness {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package emulator
+
+import "testing"
+
+// The backdrop is white on odd frames and black on even frames.
+var blinkNMI = []byte{
+	0xE6, 0x11, // INC $11
+	0xA9, 0x3F, // LDA #$3F
+	0x8D, 0x06, 0x20, // STA $2006
+	0xA9, 0x00, // LDA #$00
+	0x8D, 0x06, 0x20, // STA $2006
+	0xA5, 0x11, // LDA $11
+	0x29, 0x01, // AND #$01
+	0xAA,             // TAX
+	0xBD, 0x30, 0x81, // LDA $8130,X
+	0x8D, 0x07, 0x20, // STA $2007
+	0xA9, 0x00, // LDA #$00
+	0x8D, 0x06, 0x20, // STA $2006
+	0x8D, 0x06, 0x20, // STA $2006
+	0x40, // RTI
+}
+
+func blinkROM() []byte {
+	nmi := make([]byte, 0x32)
+	copy(nmi, blinkNMI)
+	nmi[0x30], nmi[0x31] = 0x0F, 0x30 // Black, white
+	return newTestROM(nmiLoop(0x00), nmi, nil)
+}
+
+// After the buffers are swapped in VBlank, the Zapper still sees the frame
+// the beam has just drawn at the bottom of the screen.
+func TestZapperSensesBottomLinesInVBlank(t *testing.T) {
+	e := NewEmulator(blinkROM())
+	z := e.NewZapper()
+	e.Plug(1, z)
+	e.RunFrame()
+	z.SetInputs(10, 230, false)
+
+	seen := map[bool]bool{}
+	for frame := 0; frame < 6; frame++ {
+		for _, stop := range []int{236, 245} {
+			for {
+				e.CPU.Step()
+				if _, line := e.CPU.Bus.PPU.GetBeam(); line == stop {
+					break
+				}
+			}
+			// Light reads 0 on D3.
+			isLit := z.Read()&0x08 == 0
+			c := e.GetGameScreen().RGBAAt(10, 230)
+			if stop < 240 {
+				// The pixel is in the frame being drawn.
+				c = e.Palette[e.CPU.Bus.PPU.GetBackViewport()[230*256+10]&0x1FF]
+			}
+			isWhite := c.R == 0xFF && c.G == 0xFF && c.B == 0xFF
+			if isLit != isWhite {
+				t.Fatalf("frame %d, line %d: lit = %v, white = %v", frame, stop, isLit, isWhite)
+			}
+			seen[isLit] = true
+		}
+	}
+	if len(seen) != 2 {
+		t.Fatalf("the backdrop did not blink: %v", seen)
+	}
+}
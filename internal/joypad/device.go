@@ -0,0 +1,36 @@
+package joypad
+
+import "encoding/gob"
+
+// Device is plugged into a controller port ($4016 or $4017).
+type Device interface {
+	// Read returns the bits the device drives on a read of its port (D0 ~ D4).
+	// The upper bits of the read are open bus.
+	Read() byte
+	// Write receives every $4016 write. Bit 0 is the strobe (OUT0),
+	// bits 1 and 2 are the expansion outputs (OUT1, OUT2).
+	Write(val byte)
+	// Update polls the host input once per frame.
+	Update()
+	// Reset puts the device back in its power-on state: the latched inputs
+	// and the shift registers are cleared. The host input is kept.
+	Reset()
+
+	SaveState() DeviceState
+	// LoadState fails if the state belongs to another kind of device.
+	LoadState(s DeviceState) error
+}
+
+// DeviceState is the save state of a Device.
+// Its concrete type must be registered with gob.
+type DeviceState any
+
+func init() {
+	gob.Register(State{})
+	gob.Register(FourScoreState{})
+	gob.Register(HoriState{})
+	gob.Register(ZapperState{})
+	gob.Register(VausState{})
+	gob.Register(PowerPadState{})
+	gob.Register(KeyboardState{})
+}
@@ -1,84 +1,151 @@
 package joypad
 
-import (
-	"github.com/hajimehoshi/ebiten/v2"
-)
+// InputSource supplies the live button state of a controller.
+// Poll returns the pressed buttons (bit 0 = A ... bit 7 = RIGHT).
+// Front-ends implement it on top of their own keyboard/gamepad APIs.
+type InputSource interface {
+	Poll() byte
+}
+
+// TurboSource is implemented by input sources with turbo buttons.
+// PollTurbo returns the buttons held in turbo mode, in the same bit order as Poll.
+type TurboSource interface {
+	PollTurbo() byte
+}
 
+// Joypad is the standard controller. It shifts out the 8 buttons latched
+// by the strobe, then reads 1.
+// Turbo buttons and macro playback are merged into the buttons when they are latched.
 type Joypad struct {
-	// Ebiten Inputs
-	inputs     byte
-	snapInputs byte
+	// Inputs
+	source      InputSource
+	inputs      byte
+	turboInputs byte
+	snapInputs  byte
 
-	// Others
-	isGamepadEnabled bool   // From config.toml
-	gamepadBind      [8]int // From config.toml
+	// Turbo
+	turboPeriod int // Frames of a press + release cycle
+	frame       int
+
+	// Macros
+	macro       Macro // Being played
+	macroPos    int
+	recording   Macro
+	isRecording bool
 
+	override     byte // Replaces every other input until the next Update()
+	isOverridden bool
+
+	// Others
 	setIndex  byte
 	isPolling bool
 }
 
 func NewJoypad() *Joypad {
-	return &Joypad{}
+	return &Joypad{turboPeriod: 2}
 }
 
+// Update polls the input source once per frame and advances the turbo and the macros.
+// Without a source, the state set by SetInputs() is kept.
 func (j *Joypad) Update() {
-	j.updateEbitenInputs()
-}
-
-func (j *Joypad) updateEbitenInputs() {
-	if j.isGamepadEnabled {
-		j.inputs = j.updateEbitenKeys() | j.updateEbitenGamepadButtons()
-	} else {
-		j.inputs = j.updateEbitenKeys()
+	if j.source != nil {
+		j.inputs = j.source.Poll()
+		if ts, ok := j.source.(TurboSource); ok {
+			j.turboInputs = ts.PollTurbo()
+		}
+	}
+	j.isOverridden = false
+	j.frame++
+	if j.macro != nil {
+		j.macroPos++
+		if j.macroPos >= len(j.macro) {
+			j.macro = nil
+		}
+	}
+	if j.isRecording {
+		j.recording = append(j.recording, j.Buttons())
 	}
 }
 
-func (j *Joypad) updateEbitenKeys() byte {
-	inputs := [8]bool{
-		ebiten.IsKeyPressed(ebiten.KeyZ),         // A
-		ebiten.IsKeyPressed(ebiten.KeyX),         // B
-		ebiten.IsKeyPressed(ebiten.KeyShiftLeft), // SELECT
-		ebiten.IsKeyPressed(ebiten.KeyEnter),     // START
-		ebiten.IsKeyPressed(ebiten.KeyUp),        // UP
-		ebiten.IsKeyPressed(ebiten.KeyDown),      // DOWN
-		ebiten.IsKeyPressed(ebiten.KeyLeft),      // LEFT
-		ebiten.IsKeyPressed(ebiten.KeyRight),     // RIGHT
+// Buttons returns the buttons pressed on the current frame,
+// with the turbo and the macro being played.
+func (j *Joypad) Buttons() byte {
+	if j.isOverridden {
+		return j.override
 	}
-	var keys byte
-	for i, b := range inputs {
-		if b {
-			keys |= 1 << i
-		}
+	buttons := j.inputs
+	if j.frame%j.turboPeriod < j.turboPeriod/2 {
+		buttons |= j.turboInputs
 	}
-	return keys
+	if j.macro != nil && j.macroPos >= 0 {
+		buttons |= j.macro[j.macroPos]
+	}
+	return buttons
 }
 
-func (j *Joypad) updateEbitenGamepadButtons() byte {
-	id := ebiten.GamepadID(0)
-	var inputs [8]bool
-	for i, v := range j.gamepadBind {
-		inputs[i] = ebiten.IsGamepadButtonPressed(id, ebiten.GamepadButton(v))
-	}
-	var gamepad byte
-	for i, b := range inputs {
-		if b {
-			gamepad |= 1 << i
-		}
+func (j *Joypad) SetInputSource(src InputSource) {
+	j.source = src
+}
+
+// SetInputs overrides the current button state (bit 0 = A ... bit 7 = RIGHT).
+// It is used when the emulator runs headless and no input source is set.
+func (j *Joypad) SetInputs(inputs byte) {
+	j.inputs = inputs
+}
+
+// Override replaces the buttons of the current frame, turbo and macros included,
+// until the next Update(). Movie playback uses it.
+func (j *Joypad) Override(buttons byte) {
+	j.override, j.isOverridden = buttons, true
+}
+
+// SetTurboInputs overrides the buttons held in turbo mode.
+func (j *Joypad) SetTurboInputs(inputs byte) {
+	j.turboInputs = inputs
+}
+
+// SetTurboPeriod sets the frames of a turbo press + release cycle (at least 2).
+// At 60 FPS, 2 is 30 Hz and 4 is 15 Hz.
+func (j *Joypad) SetTurboPeriod(frames int) {
+	j.turboPeriod = max(frames, 2)
+}
+
+// PlayMacro presses the buttons of the macro, one entry per frame from the
+// next Update() on, on top of the other inputs. It replaces the macro being played.
+func (j *Joypad) PlayMacro(m Macro) {
+	j.macro, j.macroPos = nil, -1
+	if len(m) > 0 {
+		j.macro = m
 	}
-	return gamepad
 }
 
-// From config.toml
-func (j *Joypad) SetIsGamepadEnabled(b bool) {
-	j.isGamepadEnabled = b
+func (j *Joypad) IsPlayingMacro() bool {
+	return j.macro != nil
+}
+
+// StartRecording records the buttons of every frame from the next Update() on.
+func (j *Joypad) StartRecording() {
+	j.recording, j.isRecording = nil, true
+}
+
+// StopRecording returns the buttons recorded since StartRecording.
+func (j *Joypad) StopRecording() Macro {
+	m := j.recording
+	j.recording, j.isRecording = nil, false
+	return m
+}
+
+func (j *Joypad) IsRecording() bool {
+	return j.isRecording
 }
 
-// From config.toml
-func (j *Joypad) SetIsGamepadBind(bind [8]int) {
-	j.gamepadBind = bind
+// Reset also restarts the turbo cycle. The macros go on.
+func (j *Joypad) Reset() {
+	j.snapInputs, j.setIndex, j.isPolling = 0, 0, false
+	j.frame = 0
 }
 
-func (j *Joypad) Read4016() byte {
+func (j *Joypad) Read() byte {
 	if j.isPolling {
 		return j.snapInputs >> 0 & 1
 	} else {
@@ -92,7 +159,7 @@ func (j *Joypad) Read4016() byte {
 	}
 }
 
-func (j *Joypad) Write4016(val byte) {
+func (j *Joypad) Write(val byte) {
 	if j.isPolling {
 		if val&1 == 0 {
 			j.isPolling = false
@@ -100,7 +167,7 @@ func (j *Joypad) Write4016(val byte) {
 	} else {
 		if val&1 == 1 {
 			j.isPolling = true
-			j.snapInputs = j.inputs
+			j.snapInputs = j.Buttons()
 			j.setIndex = 0
 		}
 	}
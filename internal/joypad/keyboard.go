@@ -0,0 +1,110 @@
+package joypad
+
+import "fmt"
+
+// KeyMatrix is the state of the Family BASIC keyboard:
+// [row][column], bit 0 ~ 3 = the key read on D1 ~ D4 (1 = pressed).
+//
+//	       Column 0                       Column 1
+//	       D4     D3     D2     D1        D4     D3     D2     D1
+//	Row 0  ]      [      RETURN F8        STOP   ¥      RSHIFT KANA
+//	Row 1  ;      :      @      F7        ^      -      /      _
+//	Row 2  K      L      O      F6        0      P      ,      .
+//	Row 3  J      U      I      F5        8      9      N      M
+//	Row 4  H      G      Y      F4        6      7      V      B
+//	Row 5  D      R      T      F3        4      5      C      F
+//	Row 6  A      S      W      F2        3      E      Z      X
+//	Row 7  CTR    Q      ESC    F1        2      1      GRPH   LSHIFT
+//	Row 8  LEFT   RIGHT  UP     CLR HOME  INS    DEL    SPACE  DOWN
+type KeyMatrix [9][2]byte
+
+// KeyboardSource supplies the keys pressed on the Family BASIC keyboard.
+type KeyboardSource interface {
+	Poll() KeyMatrix
+}
+
+// Keyboard is the Family BASIC keyboard.
+// $4016 writes: bit 0 resets the row to 0, bit 1 selects the column
+// (the row advances when it goes from 1 to 0), bit 2 enables the keyboard.
+// D1 ~ D4 read the 4 keys of the selected row and column (0 = pressed).
+type Keyboard struct {
+	source    KeyboardSource
+	keys      KeyMatrix
+	row       int
+	column    int
+	isEnabled bool
+}
+
+func NewKeyboard() *Keyboard {
+	return &Keyboard{}
+}
+
+func (k *Keyboard) SetInputSource(src KeyboardSource) {
+	k.source = src
+}
+
+// SetInputs overrides the keys pressed.
+// It is used when the emulator runs headless and no input source is set.
+func (k *Keyboard) SetInputs(keys KeyMatrix) {
+	k.keys = keys
+}
+
+// Update polls the input source once per frame.
+func (k *Keyboard) Update() {
+	if k.source != nil {
+		k.keys = k.source.Poll()
+	}
+}
+
+// After the last row, nothing is pressed.
+func (k *Keyboard) Read() byte {
+	if !k.isEnabled {
+		return 0
+	}
+	if k.row >= len(k.keys) {
+		return 0x1E
+	}
+	return ^k.keys[k.row][k.column] << 1 & 0x1E
+}
+
+func (k *Keyboard) Write(val byte) {
+	k.isEnabled = val&0x04 != 0
+	if !k.isEnabled {
+		return
+	}
+	column := int(val >> 1 & 1)
+	if k.column == 1 && column == 0 {
+		k.row++
+	}
+	k.column = column
+	if val&1 == 1 {
+		k.row = 0
+	}
+}
+
+func (k *Keyboard) Reset() {
+	k.row, k.column, k.isEnabled = 0, 0, false
+}
+
+type KeyboardState struct {
+	Keys      KeyMatrix
+	Row       int
+	Column    int
+	IsEnabled bool
+}
+
+func (k *Keyboard) SaveState() DeviceState {
+	return KeyboardState{Keys: k.keys, Row: k.row, Column: k.column, IsEnabled: k.isEnabled}
+}
+
+func (k *Keyboard) LoadState(ds DeviceState) error {
+	s, ok := ds.(KeyboardState)
+	if !ok {
+		return fmt.Errorf("keyboard: unexpected state %T", ds)
+	}
+	if s.Row < 0 || s.Column < 0 || s.Column > 1 {
+		return fmt.Errorf("keyboard: save state selects row %d column %d", s.Row, s.Column)
+	}
+	k.keys, k.row, k.column, k.isEnabled = s.Keys, s.Row, s.Column, s.IsEnabled
+	return nil
+}
@@ -0,0 +1,70 @@
+package joypad
+
+import "testing"
+
+// scanKeyboard reads the matrix the way Family BASIC does: $05 resets to
+// row 0, then $04 / $06 select columns 0 / 1, and going back to column 0
+// advances the row.
+func scanKeyboard(k *Keyboard, rows int) [][2]byte {
+	k.Write(0x05)
+	got := make([][2]byte, rows)
+	for row := range got {
+		k.Write(0x04)
+		got[row][0] = k.Read()
+		k.Write(0x06)
+		got[row][1] = k.Read()
+	}
+	return got
+}
+
+func TestKeyboardScan(t *testing.T) {
+	var keys KeyMatrix
+	keys[0][0] = 0x02 // RETURN
+	keys[6][1] = 0x02 // Z
+	keys[8][1] = 0x09 // DOWN, INS
+	k := NewKeyboard()
+	k.SetInputs(keys)
+
+	got := scanKeyboard(k, 10)
+	for row, cols := range got {
+		for col, val := range cols {
+			want := byte(0x1E) // Nothing pressed (0 = pressed), also after the last row
+			if row < len(keys) {
+				want = ^keys[row][col] << 1 & 0x1E
+			}
+			if val != want {
+				t.Errorf("row %d column %d reads %02X, want %02X", row, col, val, want)
+			}
+		}
+	}
+	if got[0][0] != 0x1A || got[8][1] != 0x0C {
+		t.Errorf("RETURN reads %02X, DOWN+INS read %02X", got[0][0], got[8][1])
+	}
+
+	// A second scan starts from row 0 again.
+	if again := scanKeyboard(k, 1); again[0] != got[0] {
+		t.Errorf("row 0 after a reset reads %v, want %v", again[0], got[0])
+	}
+}
+
+// Without bit 2 of $4016, the keyboard does not drive the data lines
+// and ignores the row and column selection.
+func TestKeyboardDisabled(t *testing.T) {
+	var keys KeyMatrix
+	keys[0][0] = 0x03
+	keys[1][0] = 0x01
+	k := NewKeyboard()
+	k.SetInputs(keys)
+
+	k.Write(0x05)
+	k.Write(0x00)
+	if v := k.Read(); v != 0 {
+		t.Errorf("disabled keyboard reads %02X", v)
+	}
+	k.Write(0x02)
+	k.Write(0x00)
+	k.Write(0x04)
+	if v := k.Read(); v != 0x18 {
+		t.Errorf("row 0 after disabled column writes reads %02X, want 18", v)
+	}
+}
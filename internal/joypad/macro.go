@@ -0,0 +1,102 @@
+package joypad
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Button names, in joypad bit order
+var ButtonNames = [8]string{"A", "B", "SELECT", "START", "UP", "DOWN", "LEFT", "RIGHT"}
+
+// ParseButtons reads a set of buttons joined by '+', e.g. "A+RIGHT" (case-insensitive).
+// "-" is no button.
+func ParseButtons(s string) (byte, error) {
+	if s == "-" {
+		return 0, nil
+	}
+	var buttons byte
+	for _, name := range strings.Split(strings.ToUpper(s), "+") {
+		found := false
+		for i, b := range ButtonNames {
+			if name == b {
+				buttons |= 1 << i
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown button %q", name)
+		}
+	}
+	return buttons, nil
+}
+
+// FormatButtons is the inverse of ParseButtons.
+func FormatButtons(buttons byte) string {
+	var names []string
+	for i, name := range ButtonNames {
+		if buttons>>i&1 == 1 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, "+")
+}
+
+// Macro is a sequence of button states, one per frame.
+type Macro []byte
+
+// ParseMacro reads a macro file.
+// One line per run of frames: "<buttons> [frames]", e.g. "START" or "DOWN+A 3".
+// The count defaults to 1. Empty lines and lines starting with '#' are ignored.
+func ParseMacro(r io.Reader) (Macro, error) {
+	var m Macro
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: want \"<buttons> [frames]\"", n)
+		}
+		buttons, err := ParseButtons(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		count := 1
+		if len(fields) == 2 {
+			if count, err = strconv.Atoi(fields[1]); err != nil || count < 1 {
+				return nil, fmt.Errorf("line %d: bad frame count %q", n, fields[1])
+			}
+		}
+		for range count {
+			m = append(m, buttons)
+		}
+	}
+	return m, sc.Err()
+}
+
+// WriteTo writes the macro in the format read by ParseMacro.
+func (m Macro) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var written int64
+	for i := 0; i < len(m); {
+		count := 1
+		for i+count < len(m) && m[i+count] == m[i] {
+			count++
+		}
+		n, err := fmt.Fprintf(bw, "%s %d\n", FormatButtons(m[i]), count)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		i += count
+	}
+	return written, bw.Flush()
+}
@@ -0,0 +1,230 @@
+package joypad
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Multitap is an adapter connecting 4 standard controllers to both ports.
+type Multitap int
+
+const (
+	NoMultitap     Multitap = iota // Controllers 1 and 2 in the ports
+	FourScore                      // NES Four Score
+	Hori                           // Famicom expansion port adapter (Hori style)
+	HoriFourPlayer                 // The Hori adapter in its 4-player mode
+)
+
+func (m Multitap) String() string {
+	switch m {
+	case FourScore:
+		return "fourscore"
+	case Hori:
+		return "hori"
+	case HoriFourPlayer:
+		return "hori4"
+	default:
+		return ""
+	}
+}
+
+// ParseMultitap reads a multitap name: "" (none), "fourscore", "hori" or "hori4".
+func ParseMultitap(s string) (Multitap, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return NoMultitap, nil
+	case "fourscore":
+		return FourScore, nil
+	case "hori":
+		return Hori, nil
+	case "hori4":
+		return HoriFourPlayer, nil
+	default:
+		return NoMultitap, fmt.Errorf("unknown multitap %q", s)
+	}
+}
+
+// Devices returns the devices to plug into ports 1 and 2
+// with the controllers of players 1 ~ 4 connected through m.
+func (m Multitap) Devices(pads [4]*Joypad) [2]Device {
+	switch m {
+	case FourScore:
+		return [2]Device{
+			&fourScorePort{pads: [2]*Joypad{pads[0], pads[2]}, signature: 0x08},
+			&fourScorePort{pads: [2]*Joypad{pads[1], pads[3]}, signature: 0x04},
+		}
+	case Hori:
+		return [2]Device{
+			&horiPort{pads: [2]*Joypad{pads[0], pads[2]}},
+			&horiPort{pads: [2]*Joypad{pads[1], pads[3]}},
+		}
+	case HoriFourPlayer:
+		return [2]Device{
+			newHoriFourPlayerPort([2]*Joypad{pads[0], pads[2]}, 0x20),
+			newHoriFourPlayerPort([2]*Joypad{pads[1], pads[3]}, 0x10),
+		}
+	default:
+		return [2]Device{pads[0], pads[1]}
+	}
+}
+
+// ===== Four Score =====
+
+// A port of the Four Score shifts out 24 bits on D0: the buttons of its
+// first controller (players 1/2), of its second one (players 3/4),
+// then a signature telling the game that a Four Score is present
+// (bit 19 on $4016, bit 18 on $4017, counting from 0). 1 is read after that.
+type fourScorePort struct {
+	pads      [2]*Joypad
+	signature byte
+	index     int
+	isStrobe  bool
+}
+
+func (f *fourScorePort) Read() byte {
+	if f.isStrobe {
+		return f.pads[0].Read()
+	}
+	var val byte
+	switch {
+	case f.index < 8:
+		val = f.pads[0].Read()
+	case f.index < 16:
+		val = f.pads[1].Read()
+	case f.index < 24:
+		val = f.signature >> (f.index - 16) & 1
+	default:
+		return 1
+	}
+	f.index++
+	return val
+}
+
+func (f *fourScorePort) Write(val byte) {
+	f.isStrobe = val&1 == 1
+	if f.isStrobe {
+		f.index = 0
+	}
+	f.pads[0].Write(val)
+	f.pads[1].Write(val)
+}
+
+func (f *fourScorePort) Update() {
+	f.pads[0].Update()
+	f.pads[1].Update()
+}
+
+func (f *fourScorePort) Reset() {
+	f.pads[0].Reset()
+	f.pads[1].Reset()
+	f.index, f.isStrobe = 0, false
+}
+
+type FourScoreState struct {
+	Pads     [2]State
+	Index    int
+	IsStrobe bool
+}
+
+func (f *fourScorePort) SaveState() DeviceState {
+	return FourScoreState{
+		Pads:     [2]State{f.pads[0].SaveState().(State), f.pads[1].SaveState().(State)},
+		Index:    f.index,
+		IsStrobe: f.isStrobe,
+	}
+}
+
+func (f *fourScorePort) LoadState(ds DeviceState) error {
+	s, ok := ds.(FourScoreState)
+	if !ok {
+		return fmt.Errorf("four score: unexpected state %T", ds)
+	}
+	for i, pad := range f.pads {
+		if err := pad.LoadState(s.Pads[i]); err != nil {
+			return err
+		}
+	}
+	f.index = s.Index
+	f.isStrobe = s.IsStrobe
+	return nil
+}
+
+// ===== Hori =====
+
+// On the Famicom, the extra controllers go through the expansion port and
+// are read on D1: players 3 and 4 on $4016 and $4017 next to the built-in
+// controllers on D0. Games read both bits to accept either controller.
+//
+// In its 4-player mode, the adapter shifts out 24 bits on D1 as the Four Score
+// does on D0: players 1 and 3 then the signature $20 on $4016 (bit 21),
+// players 2 and 4 then $10 on $4017 (bit 20). All 4 controllers are on the
+// adapter, so D0 reads 0; the games read D0 | D1 for player 1 and 2 anyway.
+type horiPort struct {
+	pads   [2]*Joypad
+	stream *fourScorePort // The 24-bit stream of the 4-player mode. nil = 2-player mode
+}
+
+func newHoriFourPlayerPort(pads [2]*Joypad, signature byte) *horiPort {
+	return &horiPort{pads: pads, stream: &fourScorePort{pads: pads, signature: signature}}
+}
+
+func (h *horiPort) Read() byte {
+	if h.stream != nil {
+		return h.stream.Read() << 1
+	}
+	return h.pads[0].Read() | h.pads[1].Read()<<1
+}
+
+func (h *horiPort) Write(val byte) {
+	if h.stream != nil {
+		h.stream.Write(val)
+		return
+	}
+	h.pads[0].Write(val)
+	h.pads[1].Write(val)
+}
+
+func (h *horiPort) Update() {
+	h.pads[0].Update()
+	h.pads[1].Update()
+}
+
+func (h *horiPort) Reset() {
+	h.pads[0].Reset()
+	h.pads[1].Reset()
+	if h.stream != nil {
+		h.stream.index, h.stream.isStrobe = 0, false
+	}
+}
+
+type HoriState struct {
+	Pads     [2]State
+	Index    int // Of the 4-player mode
+	IsStrobe bool
+}
+
+func (h *horiPort) SaveState() DeviceState {
+	s := HoriState{
+		Pads: [2]State{h.pads[0].SaveState().(State), h.pads[1].SaveState().(State)},
+	}
+	if h.stream != nil {
+		s.Index, s.IsStrobe = h.stream.index, h.stream.isStrobe
+	}
+	return s
+}
+
+func (h *horiPort) LoadState(ds DeviceState) error {
+	s, ok := ds.(HoriState)
+	if !ok {
+		return fmt.Errorf("hori: unexpected state %T", ds)
+	}
+	for i, pad := range h.pads {
+		if err := pad.LoadState(s.Pads[i]); err != nil {
+			return err
+		}
+	}
+	if h.stream != nil {
+		h.stream.index, h.stream.isStrobe = s.Index, s.IsStrobe
+	}
+	return nil
+}
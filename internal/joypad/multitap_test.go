@@ -0,0 +1,83 @@
+package joypad
+
+import "testing"
+
+// readBits strobes the port and reads n bits of the given data line.
+func readBits(d Device, bit uint, n int) []byte {
+	d.Write(1)
+	d.Write(0)
+	bits := make([]byte, n)
+	for i := range bits {
+		bits[i] = d.Read() >> bit & 1
+	}
+	return bits
+}
+
+func newPads(buttons ...byte) [4]*Joypad {
+	var pads [4]*Joypad
+	for i := range pads {
+		pads[i] = NewJoypad()
+		pads[i].SetInputs(buttons[i])
+	}
+	return pads
+}
+
+// Each port reads 24 bits: its 2 controllers, then the signature, then 1s.
+func TestFourPlayerStreams(t *testing.T) {
+	pads := newPads(0x01, 0x02, 0x80, 0x40)
+	for _, tt := range []struct {
+		multitap   Multitap
+		bit        uint
+		signatures [2]byte
+	}{
+		{FourScore, 0, [2]byte{0x08, 0x04}},
+		{HoriFourPlayer, 1, [2]byte{0x20, 0x10}},
+	} {
+		ports := tt.multitap.Devices(pads)
+		for port, d := range ports {
+			want := []byte{}
+			for _, b := range []byte{pads[port].inputs, pads[port+2].inputs, tt.signatures[port]} {
+				for i := 0; i < 8; i++ {
+					want = append(want, b>>i&1)
+				}
+			}
+			want = append(want, 1, 1)
+			got := readBits(d, tt.bit, 26)
+			if string(got) != string(want) {
+				t.Errorf("%v port %d reads %v, want %v", tt.multitap, port+1, got, want)
+			}
+			if other := readBits(d, 1-tt.bit, 26); string(other) != string(make([]byte, 26)) {
+				t.Errorf("%v port %d drives the other line: %v", tt.multitap, port+1, other)
+			}
+		}
+	}
+}
+
+// In its 2-player mode, the Hori adapter puts players 3 and 4 on D1.
+func TestHoriTwoPlayerMode(t *testing.T) {
+	pads := newPads(0x01, 0x02, 0x80, 0x40)
+	for port, d := range Hori.Devices(pads) {
+		d.Write(1)
+		d.Write(0)
+		var d0, d1 byte
+		for i := 0; i < 8; i++ {
+			v := d.Read()
+			d0 |= v & 1 << i
+			d1 |= v >> 1 & 1 << i
+		}
+		if d0 != pads[port].inputs || d1 != pads[port+2].inputs {
+			t.Errorf("port %d reads %02X on D0 and %02X on D1", port+1, d0, d1)
+		}
+	}
+}
+
+func TestParseMultitap(t *testing.T) {
+	for _, m := range []Multitap{NoMultitap, FourScore, Hori, HoriFourPlayer} {
+		if got, err := ParseMultitap(m.String()); err != nil || got != m {
+			t.Errorf("ParseMultitap(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseMultitap("hori8"); err == nil {
+		t.Error("ParseMultitap(hori8): no error")
+	}
+}
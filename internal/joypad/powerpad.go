@@ -0,0 +1,139 @@
+package joypad
+
+import "fmt"
+
+// MatSource supplies the buttons stepped on of a Power Pad / Family Trainer mat.
+// Poll returns bit 0 = button 1 ... bit 11 = button 12, numbered as printed
+// on side B of the Power Pad:
+//
+//	1  2  3  4
+//	5  6  7  8
+//	9 10 11 12
+type MatSource interface {
+	Poll() uint16
+}
+
+// Buttons shifted out on D3 and D4 of the Power Pad, in read order.
+var (
+	powerPadD3 = [8]int{2, 1, 5, 9, 6, 10, 11, 7}
+	powerPadD4 = [4]int{4, 3, 12, 8}
+)
+
+// PowerPad is the NES Power Pad.
+// After the strobe, D3 and D4 shift out the buttons (1 = stepped on)
+// in the powerPadD3 / powerPadD4 order, then read 1.
+//
+// With isFamilyTrainer, it is the Famicom Family Trainer instead:
+// $4016 bits 0 ~ 2 select rows (0 = selected, bit 2 = top row),
+// and D1 ~ D4 read the buttons of the selected rows (0 = stepped on).
+type PowerPad struct {
+	source          MatSource
+	isFamilyTrainer bool
+	buttons         uint16
+	snapD3, snapD4  uint16 // Shift registers, refilled with 1s
+	rows            byte   // Family Trainer row select
+	isPolling       bool
+}
+
+func NewPowerPad() *PowerPad {
+	return &PowerPad{}
+}
+
+func NewFamilyTrainer() *PowerPad {
+	return &PowerPad{isFamilyTrainer: true, rows: 0x07}
+}
+
+func (p *PowerPad) SetInputSource(src MatSource) {
+	p.source = src
+}
+
+// SetInputs overrides the buttons stepped on (bit 0 = button 1).
+// It is used when the emulator runs headless and no input source is set.
+func (p *PowerPad) SetInputs(buttons uint16) {
+	p.buttons = buttons & 0xFFF
+}
+
+// Update polls the input source once per frame.
+func (p *PowerPad) Update() {
+	if p.source != nil {
+		p.SetInputs(p.source.Poll())
+	}
+}
+
+func (p *PowerPad) isPressed(button int) bool {
+	return p.buttons>>(button-1)&1 == 1
+}
+
+func (p *PowerPad) latch() {
+	p.snapD3, p.snapD4 = 0xFF00, 0xFFF0
+	for i, b := range powerPadD3 {
+		if p.isPressed(b) {
+			p.snapD3 |= 1 << i
+		}
+	}
+	for i, b := range powerPadD4 {
+		if p.isPressed(b) {
+			p.snapD4 |= 1 << i
+		}
+	}
+}
+
+func (p *PowerPad) Read() byte {
+	if p.isFamilyTrainer {
+		return p.readRows()
+	}
+	if p.isPolling {
+		p.latch()
+	}
+	val := byte(p.snapD3&1)<<3 | byte(p.snapD4&1)<<4
+	p.snapD3 = p.snapD3>>1 | 0x8000
+	p.snapD4 = p.snapD4>>1 | 0x8000
+	return val
+}
+
+func (p *PowerPad) readRows() byte {
+	var pressed byte
+	for row := 0; row < 3; row++ {
+		if p.rows>>(2-row)&1 == 1 {
+			continue
+		}
+		pressed |= byte(p.buttons >> (row * 4) & 0x0F)
+	}
+	return ^pressed << 1 & 0x1E
+}
+
+func (p *PowerPad) Write(val byte) {
+	p.rows = val & 0x07
+	p.isPolling = val&1 == 1
+	if p.isPolling {
+		p.latch()
+	}
+}
+
+func (p *PowerPad) Reset() {
+	p.snapD3, p.snapD4, p.isPolling = 0, 0, false
+	p.rows = 0
+	if p.isFamilyTrainer {
+		p.rows = 0x07
+	}
+}
+
+type PowerPadState struct {
+	Buttons        uint16
+	SnapD3, SnapD4 uint16
+	Rows           byte
+	IsPolling      bool
+}
+
+func (p *PowerPad) SaveState() DeviceState {
+	return PowerPadState{Buttons: p.buttons, SnapD3: p.snapD3, SnapD4: p.snapD4, Rows: p.rows, IsPolling: p.isPolling}
+}
+
+func (p *PowerPad) LoadState(ds DeviceState) error {
+	s, ok := ds.(PowerPadState)
+	if !ok {
+		return fmt.Errorf("power pad: unexpected state %T", ds)
+	}
+	p.buttons, p.snapD3, p.snapD4, p.rows, p.isPolling = s.Buttons, s.SnapD3, s.SnapD4, s.Rows, s.IsPolling
+	return nil
+}
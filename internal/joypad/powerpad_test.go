@@ -0,0 +1,63 @@
+package joypad
+
+import "testing"
+
+func TestPowerPadSerial(t *testing.T) {
+	p := NewPowerPad()
+	p.SetInputs(1<<(1-1) | 1<<(2-1) | 1<<(8-1) | 1<<(12-1))
+
+	// D3: 2, 1, 5, 9, 6, 10, 11, 7, then 1s. D4: 4, 3, 12, 8, then 1s.
+	wantD3 := []byte{1, 1, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1}
+	wantD4 := []byte{0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	if got := readBits(p, 3, 12); string(got) != string(wantD3) {
+		t.Errorf("D3 reads %v, want %v", got, wantD3)
+	}
+	if got := readBits(p, 4, 12); string(got) != string(wantD4) {
+		t.Errorf("D4 reads %v, want %v", got, wantD4)
+	}
+	for bit := uint(0); bit < 3; bit++ {
+		if got := readBits(p, bit, 12); string(got) != string(make([]byte, 12)) {
+			t.Errorf("D%d is driven: %v", bit, got)
+		}
+	}
+}
+
+// While the strobe is high, every read returns the first buttons.
+func TestPowerPadStrobeHigh(t *testing.T) {
+	p := NewPowerPad()
+	p.SetInputs(1<<(2-1) | 1<<(4-1))
+	p.Write(1)
+	for i := 0; i < 3; i++ {
+		if v := p.Read(); v != 0x18 {
+			t.Errorf("read %d = %02X, want 18", i, v)
+		}
+	}
+	// The buttons are latched again when the strobe falls.
+	p.SetInputs(0)
+	p.Write(0)
+	if v := p.Read(); v != 0 {
+		t.Errorf("read after release = %02X, want 00", v)
+	}
+}
+
+// The Family Trainer reads the rows selected by $4016 bits 0 ~ 2
+// (0 = selected, bit 2 = top row) on D1 ~ D4 (0 = stepped on).
+func TestFamilyTrainerRows(t *testing.T) {
+	p := NewFamilyTrainer()
+	p.SetInputs(1<<(1-1) | 1<<(6-1) | 1<<(12-1))
+	for _, tt := range []struct {
+		rows byte
+		want byte
+	}{
+		{0x07, 0x1E}, // No row
+		{0x03, 0x1C}, // Buttons 1 ~ 4
+		{0x05, 0x1A}, // Buttons 5 ~ 8
+		{0x06, 0x0E}, // Buttons 9 ~ 12
+		{0x00, 0x08}, // All rows at once
+	} {
+		p.Write(tt.rows)
+		if v := p.Read(); v != tt.want {
+			t.Errorf("rows %03b read %02X, want %02X", tt.rows, v, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package joypad
+
+import "fmt"
+
+// State is a serializable snapshot of the joypad, used for save states.
+// The live inputs belong to the host, so they are not part of it.
+// The turbo phase and the macro being played are, as they change the buttons.
+type State struct {
+	SnapInputs byte
+	SetIndex   byte
+	IsPolling  bool
+
+	Frame    int
+	Macro    Macro
+	MacroPos int
+}
+
+func (j *Joypad) SaveState() DeviceState {
+	return State{
+		SnapInputs: j.snapInputs,
+		SetIndex:   j.setIndex,
+		IsPolling:  j.isPolling,
+
+		Frame:    j.frame,
+		Macro:    j.macro,
+		MacroPos: j.macroPos,
+	}
+}
+
+func (j *Joypad) LoadState(ds DeviceState) error {
+	s, ok := ds.(State)
+	if !ok {
+		return fmt.Errorf("joypad: unexpected state %T", ds)
+	}
+	if s.Macro != nil && (s.MacroPos < -1 || s.MacroPos >= len(s.Macro)) {
+		return fmt.Errorf("joypad: save state is at frame %d of a %d-frame macro", s.MacroPos, len(s.Macro))
+	}
+	j.snapInputs = s.SnapInputs
+	j.setIndex = s.SetIndex
+	j.isPolling = s.IsPolling
+	j.frame = s.Frame
+	j.macro, j.macroPos = s.Macro, s.MacroPos
+	return nil
+}
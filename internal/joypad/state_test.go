@@ -0,0 +1,59 @@
+package joypad
+
+import "testing"
+
+// A loaded state goes on with the same turbo phase and macro.
+func TestStateKeepsTurboAndMacro(t *testing.T) {
+	newPad := func() *Joypad {
+		j := NewJoypad()
+		j.SetTurboPeriod(4)
+		j.SetTurboInputs(0x01) // A
+		return j
+	}
+	buttons := func(j *Joypad, n int) []byte {
+		var b []byte
+		for i := 0; i < n; i++ {
+			j.Update()
+			b = append(b, j.Buttons())
+		}
+		return b
+	}
+
+	j := newPad()
+	j.PlayMacro(Macro{0x10, 0x20, 0x40, 0x80, 0x10, 0x20})
+	buttons(j, 3)
+	s := j.SaveState()
+	want := buttons(j, 6)
+
+	loaded := newPad()
+	buttons(loaded, 1) // Another turbo phase
+	if err := loaded.LoadState(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := buttons(loaded, 6); string(got) != string(want) {
+		t.Errorf("buttons after LoadState = %02X, want %02X", got, want)
+	}
+	if loaded.IsPlayingMacro() {
+		t.Error("the macro did not end")
+	}
+}
+
+// A multitap fails to load a state its controllers reject.
+func TestMultitapLoadStateChecksPads(t *testing.T) {
+	for _, m := range []Multitap{FourScore, Hori, HoriFourPlayer} {
+		d := m.Devices(newPads(0, 0, 0, 0))[0]
+		s := d.SaveState()
+		bad := State{Macro: Macro{0x01}, MacroPos: 1}
+		switch ms := s.(type) {
+		case FourScoreState:
+			ms.Pads[1] = bad
+			s = ms
+		case HoriState:
+			ms.Pads[1] = bad
+			s = ms
+		}
+		if err := d.LoadState(s); err == nil {
+			t.Errorf("%v: LoadState() of a bad macro position succeeded", m)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package joypad
+
+import "fmt"
+
+// VausSource supplies the knob position and button of an Arkanoid controller.
+// pos is 0.0 (left end) ~ 1.0 (right end).
+type VausSource interface {
+	Poll() (pos float64, isButton bool)
+}
+
+// The knob potentiometer of the NES Arkanoid controller covers this range.
+const (
+	vausMin = 0x62
+	vausMax = 0xF2
+)
+
+// Vaus is the NES Arkanoid controller (Vaus paddle).
+// D3 reads 1 while the button is pressed. D4 shifts out the knob position
+// latched by the strobe, inverted and MSB first, then reads 0.
+type Vaus struct {
+	source    VausSource
+	pos       float64
+	isButton  bool
+	snapPos   byte
+	isPolling bool
+}
+
+func NewVaus() *Vaus {
+	return &Vaus{pos: 0.5}
+}
+
+func (v *Vaus) SetInputSource(src VausSource) {
+	v.source = src
+}
+
+// SetInputs overrides the knob position and button.
+// It is used when the emulator runs headless and no input source is set.
+func (v *Vaus) SetInputs(pos float64, isButton bool) {
+	v.pos, v.isButton = min(max(pos, 0), 1), isButton
+}
+
+// Update polls the input source once per frame.
+func (v *Vaus) Update() {
+	if v.source != nil {
+		v.SetInputs(v.source.Poll())
+	}
+}
+
+func (v *Vaus) knob() byte {
+	return byte(vausMin + v.pos*(vausMax-vausMin) + 0.5)
+}
+
+func (v *Vaus) Read() byte {
+	if v.isPolling {
+		v.snapPos = ^v.knob()
+	}
+	val := v.snapPos >> 7 << 4
+	v.snapPos <<= 1
+	if v.isButton {
+		val |= 0x08
+	}
+	return val
+}
+
+func (v *Vaus) Write(val byte) {
+	v.isPolling = val&1 == 1
+	if v.isPolling {
+		v.snapPos = ^v.knob()
+	}
+}
+
+func (v *Vaus) Reset() {
+	v.snapPos, v.isPolling = 0, false
+}
+
+type VausState struct {
+	Pos       float64
+	IsButton  bool
+	SnapPos   byte
+	IsPolling bool
+}
+
+func (v *Vaus) SaveState() DeviceState {
+	return VausState{Pos: v.pos, IsButton: v.isButton, SnapPos: v.snapPos, IsPolling: v.isPolling}
+}
+
+func (v *Vaus) LoadState(ds DeviceState) error {
+	s, ok := ds.(VausState)
+	if !ok {
+		return fmt.Errorf("vaus: unexpected state %T", ds)
+	}
+	v.pos, v.isButton, v.snapPos, v.isPolling = s.Pos, s.IsButton, s.SnapPos, s.IsPolling
+	return nil
+}
@@ -0,0 +1,49 @@
+package joypad
+
+import "testing"
+
+// D4 shifts out the inverted knob position, MSB first, then 0s.
+// D3 is the button.
+func TestVausSerial(t *testing.T) {
+	for _, tt := range []struct {
+		pos      float64
+		isButton bool
+		wantD4   []byte
+	}{
+		{0, true, []byte{1, 0, 0, 1, 1, 1, 0, 1, 0, 0}},  // ^$62
+		{1, false, []byte{0, 0, 0, 0, 1, 1, 0, 1, 0, 0}}, // ^$F2
+		{2, false, []byte{0, 0, 0, 0, 1, 1, 0, 1, 0, 0}}, // Clamped to 1
+	} {
+		v := NewVaus()
+		v.SetInputs(tt.pos, tt.isButton)
+		if got := readBits(v, 4, 10); string(got) != string(tt.wantD4) {
+			t.Errorf("pos %v: D4 reads %v, want %v", tt.pos, got, tt.wantD4)
+		}
+		wantD3 := make([]byte, 10)
+		if tt.isButton {
+			for i := range wantD3 {
+				wantD3[i] = 1
+			}
+		}
+		if got := readBits(v, 3, 10); string(got) != string(wantD3) {
+			t.Errorf("pos %v: D3 reads %v, want %v", tt.pos, got, wantD3)
+		}
+	}
+}
+
+// The knob is latched by the strobe: moving it during the reads
+// does not change the position being shifted out.
+func TestVausLatch(t *testing.T) {
+	v := NewVaus()
+	v.SetInputs(0, false)
+	v.Write(1)
+	v.Write(0)
+	v.SetInputs(1, false)
+	var got byte
+	for i := 0; i < 8; i++ {
+		got = got<<1 | v.Read()>>4&1
+	}
+	if got != ^byte(vausMin) {
+		t.Errorf("shifted out %02X, want %02X", got, ^byte(vausMin))
+	}
+}
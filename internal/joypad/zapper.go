@@ -0,0 +1,83 @@
+package joypad
+
+import "fmt"
+
+// ZapperSource supplies the aim and trigger of a Zapper.
+// x, y are on the 256x240 screen. Anything outside aims off the screen.
+type ZapperSource interface {
+	Poll() (x, y int, isTrigger bool)
+}
+
+// Zapper is the NES light gun.
+// D3 reads 0 while its photodiode sees light, D4 reads 1 while the trigger is pulled.
+type Zapper struct {
+	source    ZapperSource
+	sense     func(x, y int) bool // Whether the screen is lit at x, y right now
+	x, y      int
+	isTrigger bool
+}
+
+// The sense function reports whether the TV is lit around x, y at the current
+// beam position. The emulator provides it (see Emulator.NewZapper).
+func NewZapper(sense func(x, y int) bool) *Zapper {
+	return &Zapper{sense: sense, x: -1, y: -1}
+}
+
+func (z *Zapper) SetInputSource(src ZapperSource) {
+	z.source = src
+}
+
+// SetInputs overrides the aim and trigger.
+// It is used when the emulator runs headless and no input source is set.
+func (z *Zapper) SetInputs(x, y int, isTrigger bool) {
+	z.x, z.y, z.isTrigger = x, y, isTrigger
+}
+
+// Inputs returns the current aim and trigger.
+func (z *Zapper) Inputs() (x, y int, isTrigger bool) {
+	return z.x, z.y, z.isTrigger
+}
+
+// Update polls the input source once per frame.
+func (z *Zapper) Update() {
+	if z.source != nil {
+		z.x, z.y, z.isTrigger = z.source.Poll()
+	}
+}
+
+// The light is sensed at the time of the read, so games poll it
+// while the beam draws the target.
+func (z *Zapper) Read() byte {
+	var val byte
+	if !z.sense(z.x, z.y) {
+		val |= 0x08
+	}
+	if z.isTrigger {
+		val |= 0x10
+	}
+	return val
+}
+
+func (z *Zapper) Write(val byte) {}
+
+// The Zapper latches nothing: it reads the aim and the trigger directly.
+func (z *Zapper) Reset() {}
+
+// The aim is part of the state so that a loaded state reads the same until the next Update().
+type ZapperState struct {
+	X, Y      int
+	IsTrigger bool
+}
+
+func (z *Zapper) SaveState() DeviceState {
+	return ZapperState{X: z.x, Y: z.y, IsTrigger: z.isTrigger}
+}
+
+func (z *Zapper) LoadState(ds DeviceState) error {
+	s, ok := ds.(ZapperState)
+	if !ok {
+		return fmt.Errorf("zapper: unexpected state %T", ds)
+	}
+	z.x, z.y, z.isTrigger = s.X, s.Y, s.IsTrigger
+	return nil
+}
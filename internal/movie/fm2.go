@@ -0,0 +1,301 @@
+// Package movie reads and writes input movies in the FM2 format of FCEUX.
+//
+// A movie is a header of "key value" lines followed by one line per frame:
+//
+//	|commands|port0|port1|port2|
+//
+// With the Four Score, the frame lines have the 4 controllers instead of
+// port0 and port1. A controller is 8 characters "RLDUTSBA", '.' or ' '
+// for a released button. A Zapper is "x y trigger q z".
+//
+// The "savestate" of a movie that starts from a save state is not the one of
+// FCEUX: it is a save state of this emulator (see Emulator.SaveState of
+// internal/emulator), marked by a "savestateFormat nesutaro" line. FCEUX
+// can't play those movies, and movies with an FCEUX save state are rejected.
+package movie
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PortType is the device recorded in a controller port.
+type PortType int
+
+const (
+	PortNone    PortType = 0
+	PortGamepad PortType = 1
+	PortZapper  PortType = 2
+)
+
+// The savestateFormat header marks a save state of this emulator.
+const saveStateFormat = "nesutaro"
+
+// Command is a console command issued on a frame.
+type Command byte
+
+const (
+	CommandReset Command = 1 << iota // Soft reset
+	CommandPower                     // Power cycle
+)
+
+type Movie struct {
+	ROMFilename   string
+	ROMChecksum   [16]byte // See Checksum. Zero = unknown
+	IsPAL         bool
+	IsFourScore   bool
+	Ports         [2]PortType // Ignored with the Four Score
+	GUID          string
+	RerecordCount int
+	Comments      []string
+	SaveState     []byte // Where the movie starts (a nesutaro save state). nil = power-on
+	Frames        []Frame
+}
+
+type Frame struct {
+	Commands Command
+	Pads     [4]byte   // bit 0 = A ... bit 7 = RIGHT. Pads 3 and 4 need the Four Score
+	Zappers  [2]Zapper // Of the ports with a Zapper
+}
+
+type Zapper struct {
+	X, Y      int
+	IsTrigger bool
+}
+
+// Checksum is the ROM checksum of FCEUX: the MD5 of the PRG and CHR ROM
+// of an iNES image.
+func Checksum(rom []byte) [16]byte {
+	if len(rom) < 0x10 {
+		return md5.Sum(nil)
+	}
+	size := 0x4000*int(rom[4]) + 0x2000*int(rom[5])
+	return md5.Sum(rom[0x10:min(0x10+size, len(rom))])
+}
+
+// New creates an empty movie with a random GUID.
+func New() *Movie {
+	var b [16]byte
+	rand.Read(b[:])
+	return &Movie{
+		GUID: fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]),
+	}
+}
+
+// Read parses an FM2 movie. Binary input logs are not supported.
+func Read(r io.Reader) (*Movie, error) {
+	m := &Movie{Ports: [2]PortType{PortGamepad, PortGamepad}}
+	var format string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20) // The save state is on one line
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.HasPrefix(line, "|") {
+			f, err := m.parseFrame(line)
+			if err != nil {
+				return nil, fmt.Errorf("fm2: line %d: %w", n, err)
+			}
+			m.Frames = append(m.Frames, f)
+			continue
+		}
+		key, val, _ := strings.Cut(line, " ")
+		if key == "savestateFormat" {
+			format = val
+			continue
+		}
+		if err := m.parseHeader(key, val); err != nil {
+			return nil, fmt.Errorf("fm2: line %d: %w", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("fm2: %w", err)
+	}
+	if m.SaveState != nil && format != saveStateFormat {
+		return nil, errors.New("fm2: savestate is not a nesutaro save state (FCEUX save states are not supported)")
+	}
+	return m, nil
+}
+
+func (m *Movie) parseHeader(key, val string) error {
+	atoi := func() (int, error) {
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("bad %s %q", key, val)
+		}
+		return i, nil
+	}
+	var err error
+	switch key {
+	case "romFilename":
+		m.ROMFilename = val
+	case "romChecksum":
+		b, isBase64 := strings.CutPrefix(val, "base64:")
+		if !isBase64 {
+			return nil // Only the base64 form is checked
+		}
+		sum, err := base64.StdEncoding.DecodeString(b)
+		if err != nil || len(sum) != len(m.ROMChecksum) {
+			return fmt.Errorf("bad romChecksum %q", val)
+		}
+		copy(m.ROMChecksum[:], sum)
+	case "palFlag":
+		var i int
+		i, err = atoi()
+		m.IsPAL = i != 0
+	case "fourscore":
+		var i int
+		i, err = atoi()
+		m.IsFourScore = i != 0
+	case "port0", "port1":
+		var i int
+		i, err = atoi()
+		if i < int(PortNone) || i > int(PortZapper) {
+			return fmt.Errorf("unknown device %d in %s", i, key)
+		}
+		m.Ports[key[4]-'0'] = PortType(i)
+	case "port2":
+		if val != "0" {
+			return errors.New("expansion port devices are not supported")
+		}
+	case "binary":
+		if val != "0" && val != "false" {
+			return errors.New("binary movies are not supported")
+		}
+	case "guid":
+		m.GUID = val
+	case "rerecordCount":
+		m.RerecordCount, err = atoi()
+	case "comment":
+		m.Comments = append(m.Comments, val)
+	case "savestate":
+		b, isBase64 := strings.CutPrefix(val, "base64:")
+		if !isBase64 {
+			return errors.New("savestate must be base64")
+		}
+		if m.SaveState, err = base64.StdEncoding.DecodeString(b); err != nil {
+			return errors.New("bad savestate")
+		}
+	}
+	return err
+}
+
+func (m *Movie) parseFrame(line string) (Frame, error) {
+	var f Frame
+	fields := strings.Split(strings.TrimPrefix(line, "|"), "|")
+	want := 3 // commands, port0, port1
+	if m.IsFourScore {
+		want = 5
+	}
+	if len(fields) < want {
+		return f, errors.New("missing ports")
+	}
+	cmd, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return f, fmt.Errorf("bad commands %q", fields[0])
+	}
+	f.Commands = Command(cmd)
+	if m.IsFourScore {
+		for i := range f.Pads {
+			f.Pads[i] = parsePad(fields[1+i])
+		}
+		return f, nil
+	}
+	for i, t := range m.Ports {
+		switch t {
+		case PortGamepad:
+			f.Pads[i] = parsePad(fields[1+i])
+		case PortZapper:
+			var trigger int
+			if _, err := fmt.Sscan(fields[1+i], &f.Zappers[i].X, &f.Zappers[i].Y, &trigger); err != nil {
+				return f, fmt.Errorf("bad zapper %q", fields[1+i])
+			}
+			f.Zappers[i].IsTrigger = trigger&1 != 0
+		}
+	}
+	return f, nil
+}
+
+// The buttons are listed from bit 7 (RIGHT) to bit 0 (A).
+func parsePad(s string) byte {
+	var buttons byte
+	for i := 0; i < len(s) && i < 8; i++ {
+		if s[i] != '.' && s[i] != ' ' {
+			buttons |= 0x80 >> i
+		}
+	}
+	return buttons
+}
+
+func formatPad(buttons byte) string {
+	const names = "RLDUTSBA"
+	var b [8]byte
+	for i := range b {
+		b[i] = '.'
+		if buttons&(0x80>>i) != 0 {
+			b[i] = names[i]
+		}
+	}
+	return string(b[:])
+}
+
+// Write writes the movie in the FM2 text format.
+func (m *Movie) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	boolInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(bw, "version 3\n")
+	fmt.Fprintf(bw, "emuVersion 0\n")
+	fmt.Fprintf(bw, "rerecordCount %d\n", m.RerecordCount)
+	fmt.Fprintf(bw, "palFlag %d\n", boolInt(m.IsPAL))
+	fmt.Fprintf(bw, "romFilename %s\n", m.ROMFilename)
+	fmt.Fprintf(bw, "romChecksum base64:%s\n", base64.StdEncoding.EncodeToString(m.ROMChecksum[:]))
+	fmt.Fprintf(bw, "guid %s\n", m.GUID)
+	fmt.Fprintf(bw, "fourscore %d\n", boolInt(m.IsFourScore))
+	fmt.Fprintf(bw, "microphone 0\n")
+	if m.IsFourScore {
+		fmt.Fprintf(bw, "port0 %d\nport1 %d\n", PortGamepad, PortGamepad)
+	} else {
+		fmt.Fprintf(bw, "port0 %d\nport1 %d\n", m.Ports[0], m.Ports[1])
+	}
+	fmt.Fprintf(bw, "port2 0\n")
+	fmt.Fprintf(bw, "FDS 0\n")
+	for _, c := range m.Comments {
+		fmt.Fprintf(bw, "comment %s\n", c)
+	}
+	if m.SaveState != nil {
+		fmt.Fprintf(bw, "savestateFormat %s\n", saveStateFormat)
+		fmt.Fprintf(bw, "savestate base64:%s\n", base64.StdEncoding.EncodeToString(m.SaveState))
+	}
+	for _, f := range m.Frames {
+		fmt.Fprintf(bw, "|%d|", f.Commands)
+		if m.IsFourScore {
+			for _, p := range f.Pads {
+				fmt.Fprintf(bw, "%s|", formatPad(p))
+			}
+		} else {
+			for i, t := range m.Ports {
+				switch t {
+				case PortGamepad:
+					bw.WriteString(formatPad(f.Pads[i]))
+				case PortZapper:
+					z := f.Zappers[i]
+					fmt.Fprintf(bw, "%d %d %d 0 0", z.X, z.Y, boolInt(z.IsTrigger))
+				}
+				bw.WriteString("|")
+			}
+		}
+		bw.WriteString("|\n") // port2
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,152 @@
+package movie
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const gamepadMovie = `version 3
+emuVersion 22020
+rerecordCount 12
+palFlag 0
+romFilename smb.nes
+romChecksum base64:ABEiM0RVZneImaq7zN3u/w==
+guid 01234567-89AB-CDEF-0123-456789ABCDEF
+fourscore 0
+microphone 0
+port0 1
+port1 1
+port2 0
+FDS 0
+comment author someone
+comment second comment
+|0|........|........||
+|0|R......A|.L....B.||
+|2|RLDUTSBA|........||
+|1|...U.S..|R.......||
+`
+
+const fourScoreMovie = `version 3
+palFlag 1
+fourscore 1
+port0 1
+port1 1
+port2 0
+|0|R.......|.L......|..D.....|...U....||
+|0|....T...|.....S..|......B.|.......A||
+`
+
+const zapperMovie = `version 3
+fourscore 0
+port0 0
+port1 2
+port2 0
+savestateFormat nesutaro
+savestate base64:c2F2ZSBzdGF0ZQ==
+|0||10 20 0 0 0||
+|0||255 239 1 0 0||
+`
+
+func TestReadFrames(t *testing.T) {
+	m, err := Read(strings.NewReader(gamepadMovie))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Frame{
+		{},
+		{Pads: [4]byte{0x81, 0x42}},
+		{Commands: CommandPower, Pads: [4]byte{0xFF}},
+		{Commands: CommandReset, Pads: [4]byte{0x14, 0x80}},
+	}
+	if !reflect.DeepEqual(m.Frames, want) {
+		t.Errorf("frames = %v, want %v", m.Frames, want)
+	}
+	if m.RerecordCount != 12 || m.ROMFilename != "smb.nes" || len(m.Comments) != 2 || m.IsPAL || m.IsFourScore {
+		t.Errorf("header = %+v", m)
+	}
+	if m.ROMChecksum != [16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF} {
+		t.Errorf("romChecksum = %X", m.ROMChecksum)
+	}
+
+	m, err = Read(strings.NewReader(fourScoreMovie))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsPAL || !m.IsFourScore || m.Frames[0].Pads != [4]byte{0x80, 0x40, 0x20, 0x10} || m.Frames[1].Pads != [4]byte{0x08, 0x04, 0x02, 0x01} {
+		t.Errorf("four score movie = %+v", m)
+	}
+
+	m, err = Read(strings.NewReader(zapperMovie))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Ports != [2]PortType{PortNone, PortZapper} || string(m.SaveState) != "save state" {
+		t.Errorf("zapper movie = %+v", m)
+	}
+	if z := m.Frames[1].Zappers[1]; z != (Zapper{255, 239, true}) {
+		t.Errorf("zapper = %+v", z)
+	}
+}
+
+// A movie read, written and read again is the same movie.
+func TestRoundTrip(t *testing.T) {
+	for name, text := range map[string]string{
+		"gamepad":    gamepadMovie,
+		"four score": fourScoreMovie,
+		"zapper":     zapperMovie,
+	} {
+		m, err := Read(strings.NewReader(text))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		m2, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("%s: reading the written movie: %v", name, err)
+		}
+		if !reflect.DeepEqual(m, m2) {
+			t.Errorf("%s: round trip changed the movie\n got %+v\nwant %+v", name, m2, m)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, text := range []string{
+		"binary 1\n",
+		"port0 3\n",
+		"port2 1\n",
+		"palFlag yes\n",
+		"romChecksum base64:AAAA\n",
+		"savestate 0123\n",
+		"savestate base64:c2F2ZSBzdGF0ZQ==\n", // An FCEUX save state
+		"savestateFormat fceux\nsavestate base64:c2F2ZSBzdGF0ZQ==\n",
+		"port0 1\nport1 1\n|0|........\n",
+		"port0 2\n|0|x y 0 0 0|........||\n",
+		"|a|........|........||\n",
+	} {
+		if _, err := Read(strings.NewReader(text)); err == nil {
+			t.Errorf("Read(%q): no error", text)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	rom := make([]byte, 0x10+0x4000+0x2000+0x10)
+	copy(rom, "NES\x1a\x01\x01")
+	rom[0x10] = 1
+	trailing := append([]byte(nil), rom...)
+	trailing[len(rom)-1] = 0xFF
+	if Checksum(rom) != Checksum(trailing) {
+		t.Error("the checksum covers bytes after the CHR ROM")
+	}
+	other := append([]byte(nil), rom...)
+	other[0x10+0x4000] = 1
+	if Checksum(rom) == Checksum(other) {
+		t.Error("the checksum misses the CHR ROM")
+	}
+}
@@ -0,0 +1,19 @@
+package bus
+
+// State is a serializable snapshot of the PPU bus, used for save states.
+type State struct {
+	VRAM [0x800]byte
+	PRAM [0x20]byte
+}
+
+func (b *Bus) SaveState() State {
+	return State{
+		VRAM: b.vram,
+		PRAM: b.pram,
+	}
+}
+
+func (b *Bus) LoadState(s State) {
+	b.vram = s.VRAM
+	b.pram = s.PRAM
+}
@@ -0,0 +1,202 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"nesutaro/internal/ppu/palette"
+)
+
+// Size of the PPU framebuffer
+const (
+	srcWidth  = 256
+	srcHeight = 240
+)
+
+// Options selects the stages of a Pipeline.
+type Options struct {
+	Signal    string             // "" (palette lookup), "composite", "svideo" or "rgb"
+	Scaler    string             // "", "scale2x", "scale3x", "hq2x" or "xbr"
+	Scanlines float64            // Darkening of every other output line, 0.0 = off ~ 1.0 = black
+	NTSC      palette.NTSCParams // Decoder settings of the composite and S-Video signals
+}
+
+// Name returns a short description of the options, e.g. "composite+hq2x+scanlines".
+// It is empty when no filter is enabled.
+func (o Options) Name() string {
+	name := ""
+	for _, s := range []string{o.Signal, o.Scaler} {
+		if s != "" {
+			name += "+" + s
+		}
+	}
+	if o.Scanlines > 0 {
+		name += "+scanlines"
+	}
+	if name == "" {
+		return ""
+	}
+	return name[1:]
+}
+
+// A scaler magnifies an image by its factor in both directions.
+// The yuvs buffer has room for the source pixels, for the scalers that
+// compare them in YUV.
+type scaler struct {
+	factor int
+	scale  func(dst, src []uint32, yuvs []yuv, w, h int)
+}
+
+var scalers = map[string]scaler{
+	"scale2x": {2, scale2x},
+	"scale3x": {3, scale3x},
+	"hq2x":    {2, hq2x},
+	"xbr":     {2, xbr2x},
+}
+
+// Pipeline converts PPU frames (9-bit pixel indices) to RGBA on the CPU:
+// signal (palette lookup or NTSC simulation) -> scaler -> scanlines.
+type Pipeline struct {
+	opts   Options
+	ntsc   *ntscFilter
+	scaler *scaler
+	sx, sy int // Output size / PPU framebuffer size
+	frame  int
+	buf    [2][]uint32 // Packed RGBA pixels (R in the low byte)
+	yuvs   []yuv       // The scaler input in YUV
+	out    *image.RGBA
+}
+
+func New(opts Options) (*Pipeline, error) {
+	p := &Pipeline{opts: opts, sx: 1, sy: 1}
+
+	switch opts.Signal {
+	case "":
+	case "composite", "svideo", "rgb":
+		p.ntsc = newNTSCFilter(opts.Signal, opts.NTSC)
+		p.sx = ntscFactor
+	default:
+		return nil, fmt.Errorf("unknown signal filter %q", opts.Signal)
+	}
+
+	if opts.Scaler != "" {
+		s, ok := scalers[opts.Scaler]
+		if !ok {
+			return nil, fmt.Errorf("unknown scaler %q", opts.Scaler)
+		}
+		p.scaler = &s
+		p.yuvs = make([]yuv, srcWidth*p.sx*srcHeight)
+		p.sx *= s.factor
+		p.sy *= s.factor
+	}
+
+	if opts.Scanlines < 0 || opts.Scanlines > 1 {
+		return nil, fmt.Errorf("scanlines must be 0.0 ~ 1.0 (%g given)", opts.Scanlines)
+	}
+	if opts.Scanlines > 0 && p.sy == 1 {
+		// Scanlines need at least 2 output lines per PPU line.
+		p.sy = 2
+	}
+
+	size := srcWidth * p.sx * srcHeight * p.sy
+	p.buf[0] = make([]uint32, size)
+	p.buf[1] = make([]uint32, size)
+	p.out = image.NewRGBA(image.Rect(0, 0, srcWidth*p.sx, srcHeight*p.sy))
+	return p, nil
+}
+
+// Scale returns the output size relative to the 256x240 PPU frame.
+func (p *Pipeline) Scale() (int, int) {
+	return p.sx, p.sy
+}
+
+// Apply filters a PPU frame. pal is used by the palette lookup and RGB signal.
+// The returned image is reused by the next call.
+func (p *Pipeline) Apply(src []uint16, pal *palette.Palette) *image.RGBA {
+	w, h := srcWidth, srcHeight
+	cur, next := p.buf[0], p.buf[1]
+
+	if p.ntsc != nil {
+		p.ntsc.apply(cur, src, pal, p.frame)
+		w *= ntscFactor
+	} else {
+		for i, idx := range src {
+			c := pal[idx&0x1FF]
+			cur[i] = pack(c.R, c.G, c.B)
+		}
+	}
+	p.frame++
+
+	if p.scaler != nil {
+		p.scaler.scale(next, cur, p.yuvs, w, h)
+		cur, next = next, cur
+		w *= p.scaler.factor
+		h *= p.scaler.factor
+	}
+
+	if p.opts.Scanlines > 0 {
+		if h == srcHeight {
+			doubleLines(next, cur, w, h)
+			cur = next
+			h *= 2
+		}
+		scanlines(cur, w, h, h/srcHeight, p.opts.Scanlines)
+	}
+
+	for i, c := range cur[:w*h] {
+		p.out.Pix[i*4+0] = byte(c)
+		p.out.Pix[i*4+1] = byte(c >> 8)
+		p.out.Pix[i*4+2] = byte(c >> 16)
+		p.out.Pix[i*4+3] = 255
+	}
+	return p.out
+}
+
+// ===== Scanlines =====
+
+func doubleLines(dst, src []uint32, w, h int) {
+	for y := 0; y < h; y++ {
+		line := src[y*w : (y+1)*w]
+		copy(dst[(y*2)*w:], line)
+		copy(dst[(y*2+1)*w:], line)
+	}
+}
+
+// The last output line of each PPU line is darkened.
+func scanlines(pix []uint32, w, h, linesPerRow int, intensity float64) {
+	k := uint32((1 - intensity) * 256)
+	for y := linesPerRow - 1; y < h; y += linesPerRow {
+		line := pix[y*w : (y+1)*w]
+		for i, c := range line {
+			r := (c & 0xFF) * k >> 8
+			g := (c >> 8 & 0xFF) * k >> 8
+			b := (c >> 16 & 0xFF) * k >> 8
+			line[i] = r | g<<8 | b<<16
+		}
+	}
+}
+
+// ===== Pixels =====
+
+func pack(r, g, b byte) uint32 {
+	return uint32(r) | uint32(g)<<8 | uint32(b)<<16
+}
+
+// blend mixes pixels with integer weights.
+func blend(c []uint32, weights []uint32) uint32 {
+	var r, g, b, total uint32
+	for i, w := range weights {
+		r += (c[i] & 0xFF) * w
+		g += (c[i] >> 8 & 0xFF) * w
+		b += (c[i] >> 16 & 0xFF) * w
+		total += w
+	}
+	return (r / total) | (g/total)<<8 | (b/total)<<16
+}
+
+func blend2(a, b uint32, wa, wb uint32) uint32 {
+	return blend([]uint32{a, b}, []uint32{wa, wb})
+}
+
+func blend3(a, b, c uint32, wa, wb, wc uint32) uint32 {
+	return blend([]uint32{a, b, c}, []uint32{wa, wb, wc})
+}
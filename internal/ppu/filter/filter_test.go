@@ -0,0 +1,309 @@
+package filter
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"nesutaro/internal/ppu/palette"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+const (
+	leftColor  = 0x21 // Light blue
+	rightColor = 0x16 // Red
+	lineColor  = 0x30 // White
+)
+
+// testFrame has two flat halves and a diagonal line on the right half.
+func testFrame() []uint16 {
+	src := make([]uint16, srcWidth*srcHeight)
+	for y := 0; y < srcHeight; y++ {
+		for x := 0; x < srcWidth; x++ {
+			switch {
+			case x < srcWidth/2:
+				src[y*srcWidth+x] = leftColor
+			case x-srcWidth/2 == y/2:
+				src[y*srcWidth+x] = lineColor
+			default:
+				src[y*srcWidth+x] = rightColor
+			}
+		}
+	}
+	return src
+}
+
+func rgbaAt(img *image.RGBA, x, y int) [4]byte {
+	i := img.PixOffset(x, y)
+	return [4]byte(img.Pix[i : i+4])
+}
+
+func paletteRGBA(pal *palette.Palette, idx int) [4]byte {
+	c := pal[idx]
+	return [4]byte{c.R, c.G, c.B, 255}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, opts := range []Options{
+		{Signal: "pal"},
+		{Scaler: "hq3x"},
+		{Scanlines: -0.1},
+		{Scanlines: 1.5},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v): no error", opts)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	for _, tt := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, ""},
+		{Options{Scaler: "xbr"}, "xbr"},
+		{Options{Signal: "composite", Scaler: "hq2x", Scanlines: 0.5}, "composite+hq2x+scanlines"},
+		{Options{Scanlines: 0.3}, "scanlines"},
+	} {
+		if got := tt.opts.Name(); got != tt.want {
+			t.Errorf("%+v.Name() = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+// The scalers keep flat areas as they are, so pixels away from the edges
+// are the palette colors.
+func TestScalers(t *testing.T) {
+	pal := palette.Default()
+	for _, tt := range []struct {
+		scaler string
+		factor int
+	}{
+		{"", 1},
+		{"scale2x", 2},
+		{"scale3x", 3},
+		{"hq2x", 2},
+		{"xbr", 2},
+	} {
+		p, err := New(Options{Scaler: tt.scaler})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sx, sy := p.Scale(); sx != tt.factor || sy != tt.factor {
+			t.Errorf("%q: Scale() = %d, %d", tt.scaler, sx, sy)
+		}
+		img := p.Apply(testFrame(), pal)
+		if w, h := img.Rect.Dx(), img.Rect.Dy(); w != srcWidth*tt.factor || h != srcHeight*tt.factor {
+			t.Errorf("%q: output is %dx%d", tt.scaler, w, h)
+		}
+		for _, px := range []struct{ x, y, idx int }{
+			{0, 0, leftColor},
+			{32, 120, leftColor},
+			{100, 239, leftColor},
+			{250, 10, rightColor},
+			{160, 200, rightColor},
+		} {
+			for sy := 0; sy < tt.factor; sy++ {
+				for sx := 0; sx < tt.factor; sx++ {
+					x, y := px.x*tt.factor+sx, px.y*tt.factor+sy
+					if got, want := rgbaAt(img, x, y), paletteRGBA(pal, px.idx); got != want {
+						t.Errorf("%q: pixel (%d, %d) = %v, want %v", tt.scaler, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// The edge of the diagonal line is smoothed by the blending scalers only.
+func TestScalerEdges(t *testing.T) {
+	pal := palette.Default()
+	white, red := paletteRGBA(pal, lineColor), paletteRGBA(pal, rightColor)
+	for _, tt := range []struct {
+		scaler     string
+		isBlending bool
+	}{
+		{"hq2x", true},
+		{"xbr", true},
+		{"scale2x", false},
+	} {
+		p, err := New(Options{Scaler: tt.scaler})
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := p.Apply(testFrame(), pal)
+		// The line pixel at (178, 100) has red on its right.
+		got := rgbaAt(img, 178*2+1, 100*2)
+		isBlended := got != white && got != red
+		if isBlended != tt.isBlending {
+			t.Errorf("%q: edge pixel = %v", tt.scaler, got)
+		}
+	}
+}
+
+// A lone pixel differs from its 8 neighbors, which do not differ from each
+// other: hq2x gives each of its 4 output pixels 14/16 of the center color
+// and 1/16 of each of its 2 nearest neighbors.
+func TestHQ2xLonePixel(t *testing.T) {
+	pal := palette.Default()
+	src := make([]uint16, srcWidth*srcHeight)
+	for i := range src {
+		src[i] = rightColor
+	}
+	src[100*srcWidth+50] = lineColor
+	p, err := New(Options{Scaler: "hq2x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := p.Apply(src, pal)
+	white, red := paletteRGBA(pal, lineColor), paletteRGBA(pal, rightColor)
+	var want [4]byte
+	for i := 0; i < 3; i++ {
+		want[i] = byte((14*int(white[i]) + 2*int(red[i])) / 16)
+	}
+	want[3] = 255
+	for _, xy := range [][2]int{{100, 200}, {101, 200}, {100, 201}, {101, 201}} {
+		if got := rgbaAt(img, xy[0], xy[1]); got != want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", xy[0], xy[1], got, want)
+		}
+	}
+	// The neighbors are not blended with it.
+	for _, xy := range [][2]int{{99, 200}, {102, 201}, {100, 199}, {101, 202}, {99, 199}} {
+		if got := rgbaAt(img, xy[0], xy[1]); got != red {
+			t.Errorf("pixel (%d, %d) = %v, want %v", xy[0], xy[1], got, red)
+		}
+	}
+}
+
+func TestScanlines(t *testing.T) {
+	pal := palette.Default()
+	for _, tt := range []struct {
+		scaler         string
+		linesPerRow    int
+		wantW, wantH   int
+		wantScaleX     int
+		wantScaleY     int
+		scanlineAmount float64
+	}{
+		{"", 2, 256, 480, 1, 2, 0.5},
+		{"scale3x", 3, 768, 720, 3, 3, 0.25},
+		{"", 2, 256, 480, 1, 2, 1},
+	} {
+		p, err := New(Options{Scaler: tt.scaler, Scanlines: tt.scanlineAmount})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sx, sy := p.Scale(); sx != tt.wantScaleX || sy != tt.wantScaleY {
+			t.Errorf("%q: Scale() = %d, %d", tt.scaler, sx, sy)
+		}
+		img := p.Apply(testFrame(), pal)
+		if w, h := img.Rect.Dx(), img.Rect.Dy(); w != tt.wantW || h != tt.wantH {
+			t.Errorf("%q: output is %dx%d", tt.scaler, w, h)
+		}
+		want := paletteRGBA(pal, leftColor)
+		k := int((1 - tt.scanlineAmount) * 256)
+		dark := [4]byte{byte(int(want[0]) * k >> 8), byte(int(want[1]) * k >> 8), byte(int(want[2]) * k >> 8), 255}
+		for y := 0; y < tt.linesPerRow*4; y++ {
+			want := want
+			if y%tt.linesPerRow == tt.linesPerRow-1 {
+				want = dark
+			}
+			if got := rgbaAt(img, 10, y); got != want {
+				t.Errorf("%q %g: line %d = %v, want %v", tt.scaler, tt.scanlineAmount, y, got, want)
+			}
+		}
+	}
+}
+
+// The NTSC signals are compared with golden images, as their colors are
+// computed rather than looked up. Run with -update to rewrite them.
+func TestSignals(t *testing.T) {
+	pal := palette.Default()
+	for _, signal := range []string{"composite", "svideo", "rgb"} {
+		p, err := New(Options{Signal: signal, NTSC: palette.DefaultNTSCParams()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sx, sy := p.Scale(); sx != ntscFactor || sy != 1 {
+			t.Errorf("%q: Scale() = %d, %d", signal, sx, sy)
+		}
+		img := p.Apply(testFrame(), pal)
+		if w, h := img.Rect.Dx(), img.Rect.Dy(); w != srcWidth*ntscFactor || h != srcHeight {
+			t.Errorf("%q: output is %dx%d", signal, w, h)
+		}
+
+		path := filepath.Join("testdata", signal+".png")
+		if *update {
+			if err := writePNG(path, img); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := readPNG(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := compareImages(img, want); err != nil {
+			t.Errorf("%q: %v", signal, err)
+		}
+	}
+}
+
+func TestApplyDoesNotAllocate(t *testing.T) {
+	pal := palette.Default()
+	src := testFrame()
+	for _, opts := range []Options{
+		{},
+		{Scaler: "xbr", Scanlines: 0.5},
+		{Signal: "composite", Scaler: "hq2x"},
+	} {
+		p, err := New(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := testing.AllocsPerRun(3, func() { p.Apply(src, pal) }); n != 0 {
+			t.Errorf("%q: %g allocations per frame", opts.Name(), n)
+		}
+	}
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func compareImages(got *image.RGBA, want image.Image) error {
+	if got.Rect != want.Bounds() {
+		return fmt.Errorf("size is %v, want %v", got.Rect, want.Bounds())
+	}
+	for y := got.Rect.Min.Y; y < got.Rect.Max.Y; y++ {
+		for x := got.Rect.Min.X; x < got.Rect.Max.X; x++ {
+			r, g, b, _ := want.At(x, y).RGBA()
+			if c := rgbaAt(got, x, y); c != [4]byte{byte(r >> 8), byte(g >> 8), byte(b >> 8), 255} {
+				return fmt.Errorf("pixel (%d, %d) = %v, want %v", x, y, c, want.At(x, y))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package filter
+
+// hq2x is Maxim Stepin's hq2x. Each of the 8 neighbors that differs from the
+// center (with the hqx YUV thresholds of isDifferent) sets a bit of a pattern:
+//
+//	0 1 2
+//	3 E 4    (bit numbers; E = center)
+//	5 6 7
+//
+// The pattern, and for some patterns whether two neighbors differ from each
+// other, picks how each output pixel blends the center with its neighbors.
+// The 256 cases of the original table are folded into the rules of pixel()
+// (as in FFmpeg's vf_hqx), written for the top-left output pixel; the other
+// output pixels mirror the neighborhood.
+func hq2x(dst, src []uint32, yuvs []yuv, w, h int) {
+	toYUVs(yuvs, src, w, h)
+	dw := w * 2
+	var n hq2xNeighbors
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for i := 0; i < 9; i++ {
+				px := min(max(x+i%3-1, 0), w-1)
+				py := min(max(y+i/3-1, 0), h-1)
+				n.c[i], n.yuv[i] = src[py*w+px], yuvs[py*w+px]
+			}
+			n.pattern = 0
+			for i, bit := range hq2xBits {
+				if isDifferent(n.yuv[4], n.yuv[i]) {
+					n.pattern |= bit
+				}
+			}
+			i := y*2*dw + x*2
+			dst[i] = n.pixel(&hq2xCorners[0])
+			dst[i+1] = n.pixel(&hq2xCorners[1])
+			dst[i+dw] = n.pixel(&hq2xCorners[2])
+			dst[i+dw+1] = n.pixel(&hq2xCorners[3])
+		}
+	}
+}
+
+// Pattern bit of each pixel of the neighborhood (row by row)
+var hq2xBits = [9]int{1 << 0, 1 << 1, 1 << 2, 1 << 3, 0, 1 << 4, 1 << 5, 1 << 6, 1 << 7}
+
+// The neighborhood seen from each output pixel, as if it were the top-left one:
+// identity, horizontal mirror, vertical mirror and both.
+var hq2xCorners = [4][9]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8},
+	{2, 1, 0, 5, 4, 3, 8, 7, 6},
+	{6, 7, 8, 3, 4, 5, 0, 1, 2},
+	{8, 7, 6, 5, 4, 3, 2, 1, 0},
+}
+
+type hq2xNeighbors struct {
+	c       [9]uint32
+	yuv     [9]yuv
+	pattern int
+}
+
+// pixel computes the top-left output pixel of the neighborhood seen through m.
+func (n *hq2xNeighbors) pixel(m *[9]int) uint32 {
+	k := 0
+	for i, bit := range hq2xBits {
+		if n.pattern&hq2xBits[m[i]] != 0 {
+			k |= bit
+		}
+	}
+	is := func(mask, want int) bool { return k&mask == want }
+	differ := func(a, b int) bool { return isDifferent(n.yuv[m[a]], n.yuv[m[b]]) }
+	w0, w1, w3, w4 := n.c[m[0]], n.c[m[1]], n.c[m[3]], n.c[m[4]]
+
+	switch {
+	case (is(0xBF, 0x37) || is(0xDB, 0x13)) && differ(1, 5):
+		return blend2(w4, w3, 3, 1)
+	case (is(0xDB, 0x49) || is(0xEF, 0x6D)) && differ(7, 3):
+		return blend2(w4, w1, 3, 1)
+	case (is(0x0B, 0x0B) || is(0xFE, 0x4A) || is(0xFE, 0x1A)) && differ(3, 1):
+		return w4
+	case (is(0x6F, 0x2A) || is(0x5B, 0x0A) || is(0xBF, 0x3A) || is(0xDF, 0x5A) ||
+		is(0x9F, 0x8A) || is(0xCF, 0x8A) || is(0xEF, 0x4E) || is(0x3F, 0x0E) ||
+		is(0xFB, 0x5A) || is(0xBB, 0x8A) || is(0x7F, 0x5A) || is(0xAF, 0x8A) ||
+		is(0xEB, 0x8A)) && differ(3, 1):
+		return blend2(w4, w0, 3, 1)
+	case is(0x0B, 0x08):
+		return blend3(w4, w0, w1, 2, 1, 1)
+	case is(0x0B, 0x02):
+		return blend3(w4, w0, w3, 2, 1, 1)
+	case is(0x2F, 0x2F):
+		return blend3(w4, w3, w1, 14, 1, 1)
+	case is(0xBF, 0x37) || is(0xDB, 0x13):
+		return blend3(w4, w1, w3, 5, 2, 1)
+	case is(0xDB, 0x49) || is(0xEF, 0x6D):
+		return blend3(w4, w3, w1, 5, 2, 1)
+	case is(0x1B, 0x03) || is(0x4F, 0x43) || is(0x8B, 0x83) || is(0x6B, 0x43):
+		return blend2(w4, w3, 3, 1)
+	case is(0x4B, 0x09) || is(0x8B, 0x89) || is(0x1F, 0x19) || is(0x3B, 0x19):
+		return blend2(w4, w1, 3, 1)
+	case is(0x7E, 0x2A) || is(0xEF, 0xAB) || is(0xBF, 0x8F) || is(0x7E, 0x0E):
+		return blend3(w4, w3, w1, 2, 3, 3)
+	case is(0xFB, 0x6A) || is(0x6F, 0x6E) || is(0x3F, 0x3E) || is(0xFB, 0xFA) ||
+		is(0xDF, 0xDE) || is(0xDF, 0x1E):
+		return blend2(w4, w0, 3, 1)
+	case is(0x0A, 0x00) || is(0x4F, 0x4B) || is(0x9F, 0x1B) || is(0x2F, 0x0B) ||
+		is(0xBE, 0x0A) || is(0xEE, 0x0A) || is(0x7E, 0x0A) || is(0xEB, 0x4B) ||
+		is(0x3B, 0x1B):
+		return blend3(w4, w3, w1, 2, 1, 1)
+	}
+	return blend3(w4, w3, w1, 6, 1, 1)
+}
@@ -0,0 +1,147 @@
+package filter
+
+import (
+	"nesutaro/internal/ppu/palette"
+)
+
+// The NTSC filter outputs 2 pixels per PPU pixel.
+const ntscFactor = 2
+
+const (
+	samplesPerPixel = 8 // Subcarrier phases per PPU pixel (12 per color cycle)
+	samplesPerLine  = srcWidth * samplesPerPixel
+	ntscPad         = 6 // Black samples on each side of a line
+	gammaLUTSize    = 1024
+)
+
+// ntscFilter simulates the PPU's video signal: each pixel is modulated as
+// 8 composite signal samples, which are decoded back to YIQ by averaging
+// them over one color cycle, as in Blargg's nes_ntsc.
+//
+//	composite: luma and chroma share the signal (color fringes, dot crawl).
+//	svideo:    luma is separate (sharp, but chroma still bleeds).
+//	rgb:       the palette colors, softened only at pixel edges.
+type ntscFilter struct {
+	mode     string
+	params   palette.NTSCParams
+	signal   [512][12]float64
+	luma     [512]float64
+	cos, sin [12]float64
+	lut      [gammaLUTSize + 1]byte
+
+	// Prefix sums of a line
+	sumY, sumI, sumQ [samplesPerLine + ntscPad*2 + 1]float64
+}
+
+func newNTSCFilter(mode string, params palette.NTSCParams) *ntscFilter {
+	f := &ntscFilter{mode: mode, params: params}
+	for idx := range f.signal {
+		for phase := 0; phase < 12; phase++ {
+			f.signal[idx][phase] = palette.Signal(idx, phase)
+			f.luma[idx] += f.signal[idx][phase] / 12
+		}
+	}
+	for phase := 0; phase < 12; phase++ {
+		f.cos[phase] = palette.PhaseCos(float64(phase) + params.Hue/30)
+		f.sin[phase] = palette.PhaseSin(float64(phase) + params.Hue/30)
+	}
+	for i := range f.lut {
+		f.lut[i] = palette.GammaToByte(float64(i)/gammaLUTSize, params.Gamma)
+	}
+	return f
+}
+
+func (f *ntscFilter) apply(dst []uint32, src []uint16, pal *palette.Palette, frame int) {
+	if f.mode == "rgb" {
+		f.applyRGB(dst, src, pal)
+		return
+	}
+
+	// A line starts 4 phases after the previous one (341 dots * 8 phases).
+	// A frame starts 4 or 8 phases after the previous one, because of the
+	// dot skipped on odd frames, so the phase alternates between 2 values.
+	framePhase := (frame & 1) * 4
+	for y := 0; y < srcHeight; y++ {
+		f.sumLine(src[y*srcWidth:(y+1)*srcWidth], (framePhase+y*4)%12)
+		f.decodeLine(dst[y*srcWidth*ntscFactor : (y+1)*srcWidth*ntscFactor])
+	}
+}
+
+// sumLine modulates a line and stores the prefix sums of luma and demodulated chroma.
+func (f *ntscFilter) sumLine(line []uint16, linePhase int) {
+	isSVideo := f.mode == "svideo"
+	var y, i, q float64
+	for k := 0; k < samplesPerLine+ntscPad*2; k++ {
+		x := k - ntscPad
+		if x >= 0 && x < samplesPerLine {
+			idx := line[x/samplesPerPixel] & 0x1FF
+			phase := (linePhase + x) % 12
+			v := f.signal[idx][phase]
+			if isSVideo {
+				// Chroma is the signal without its luma.
+				y += f.luma[idx]
+				v -= f.luma[idx]
+			} else {
+				y += v
+			}
+			i += v * f.cos[phase]
+			q += v * f.sin[phase]
+		}
+		f.sumY[k+1] = y
+		f.sumI[k+1] = i
+		f.sumQ[k+1] = q
+	}
+}
+
+func (f *ntscFilter) decodeLine(dst []uint32) {
+	// Composite luma is low-passed over a color cycle to remove chroma,
+	// S-Video luma only over half a pixel.
+	lumaWindow := 12
+	if f.mode == "svideo" {
+		lumaWindow = samplesPerPixel / ntscFactor
+	}
+	step := samplesPerPixel / ntscFactor
+	for j := range dst {
+		c := ntscPad + j*step + step/2
+		y := (f.sumY[c+lumaWindow/2] - f.sumY[c-lumaWindow/2]) / float64(lumaWindow)
+		i := (f.sumI[c+6] - f.sumI[c-6]) / 12
+		q := (f.sumQ[c+6] - f.sumQ[c-6]) / 12
+
+		y = y*f.params.Contrast + f.params.Brightness
+		i *= f.params.Saturation
+		q *= f.params.Saturation
+		r, g, b := palette.YIQToRGB(y, i, q)
+		dst[j] = pack(f.gamma(r), f.gamma(g), f.gamma(b))
+	}
+}
+
+func (f *ntscFilter) gamma(v float64) byte {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return f.lut[gammaLUTSize]
+	}
+	return f.lut[int(v*gammaLUTSize+0.5)]
+}
+
+// applyRGB blends a quarter of the neighbor into each half pixel.
+func (f *ntscFilter) applyRGB(dst []uint32, src []uint16, pal *palette.Palette) {
+	for y := 0; y < srcHeight; y++ {
+		line := src[y*srcWidth : (y+1)*srcWidth]
+		out := dst[y*srcWidth*ntscFactor : (y+1)*srcWidth*ntscFactor]
+		for x := range line {
+			cur := pal[line[x]&0x1FF]
+			prev, next := cur, cur
+			if x > 0 {
+				prev = pal[line[x-1]&0x1FF]
+			}
+			if x < srcWidth-1 {
+				next = pal[line[x+1]&0x1FF]
+			}
+			c := pack(cur.R, cur.G, cur.B)
+			out[x*2] = blend2(c, pack(prev.R, prev.G, prev.B), 3, 1)
+			out[x*2+1] = blend2(c, pack(next.R, next.G, next.B), 3, 1)
+		}
+	}
+}
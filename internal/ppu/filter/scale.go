@@ -0,0 +1,135 @@
+package filter
+
+// Neighbors of a pixel, clamped at the image edges.
+//
+//	A B C
+//	D E F
+//	G H I
+type neighbors struct {
+	A, B, C, D, E, F, G, H, I uint32
+}
+
+func at(src []uint32, w, h, x, y int) uint32 {
+	x = min(max(x, 0), w-1)
+	y = min(max(y, 0), h-1)
+	return src[y*w+x]
+}
+
+func neighborsAt(src []uint32, w, h, x, y int) neighbors {
+	return neighbors{
+		at(src, w, h, x-1, y-1), at(src, w, h, x, y-1), at(src, w, h, x+1, y-1),
+		at(src, w, h, x-1, y), src[y*w+x], at(src, w, h, x+1, y),
+		at(src, w, h, x-1, y+1), at(src, w, h, x, y+1), at(src, w, h, x+1, y+1),
+	}
+}
+
+// ===== Scale2x / Scale3x (AdvanceMAME) =====
+
+func scale2x(dst, src []uint32, _ []yuv, w, h int) {
+	dw := w * 2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := neighborsAt(src, w, h, x, y)
+			e0, e1, e2, e3 := n.E, n.E, n.E, n.E
+			if n.B != n.H && n.D != n.F {
+				if n.D == n.B {
+					e0 = n.D
+				}
+				if n.B == n.F {
+					e1 = n.F
+				}
+				if n.D == n.H {
+					e2 = n.D
+				}
+				if n.H == n.F {
+					e3 = n.F
+				}
+			}
+			i := y*2*dw + x*2
+			dst[i], dst[i+1] = e0, e1
+			dst[i+dw], dst[i+dw+1] = e2, e3
+		}
+	}
+}
+
+func scale3x(dst, src []uint32, _ []yuv, w, h int) {
+	dw := w * 3
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := neighborsAt(src, w, h, x, y)
+			var e [9]uint32
+			for i := range e {
+				e[i] = n.E
+			}
+			if n.B != n.H && n.D != n.F {
+				if n.D == n.B {
+					e[0] = n.D
+				}
+				if (n.D == n.B && n.E != n.C) || (n.B == n.F && n.E != n.A) {
+					e[1] = n.B
+				}
+				if n.B == n.F {
+					e[2] = n.F
+				}
+				if (n.D == n.B && n.E != n.G) || (n.D == n.H && n.E != n.A) {
+					e[3] = n.D
+				}
+				if (n.B == n.F && n.E != n.I) || (n.H == n.F && n.E != n.C) {
+					e[5] = n.F
+				}
+				if n.D == n.H {
+					e[6] = n.D
+				}
+				if (n.D == n.H && n.E != n.I) || (n.H == n.F && n.E != n.G) {
+					e[7] = n.H
+				}
+				if n.H == n.F {
+					e[8] = n.F
+				}
+			}
+			for row := 0; row < 3; row++ {
+				copy(dst[(y*3+row)*dw+x*3:], e[row*3:row*3+3])
+			}
+		}
+	}
+}
+
+// ===== YUV =====
+
+// Pixels are compared in YUV, as hqx and xBR do.
+type yuv struct {
+	y, u, v int
+}
+
+func toYUV(c uint32) yuv {
+	r, g, b := int(c&0xFF), int(c>>8&0xFF), int(c>>16&0xFF)
+	return yuv{
+		y: (299*r + 587*g + 114*b) / 1000,
+		u: (-169*r - 331*g + 500*b) / 1000,
+		v: (500*r - 419*g - 81*b) / 1000,
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// The hqx thresholds
+func isDifferent(a, b yuv) bool {
+	return abs(a.y-b.y) > 48 || abs(a.u-b.u) > 7 || abs(a.v-b.v) > 6
+}
+
+// The xBR weighted distance
+func distance(a, b yuv) int {
+	return 48*abs(a.y-b.y) + 7*abs(a.u-b.u) + 6*abs(a.v-b.v)
+}
+
+// toYUVs converts the pixels into dst, which is reused across frames.
+func toYUVs(dst []yuv, src []uint32, w, h int) {
+	for i, c := range src[:w*h] {
+		dst[i] = toYUV(c)
+	}
+}
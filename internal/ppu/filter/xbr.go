@@ -0,0 +1,48 @@
+package filter
+
+// xbr2x is Hyllian's 2xBR (level 1). For each corner of a pixel, the
+// weighted color distances along the two diagonals decide whether an edge
+// crosses the corner; if so, the corner is blended toward the closer neighbor.
+//
+//	   A1 B1 C1
+//	A0 A  B  C  C4
+//	D0 D  E  F  F4
+//	G0 G  H  I  I4
+//	   G5 H5 I5
+//
+// The rules are written for the bottom-right corner (F, H, I); the other
+// corners mirror the neighborhood, which leaves the rules unchanged.
+func xbr2x(dst, src []uint32, yuvs []yuv, w, h int) {
+	toYUVs(yuvs, src, w, h)
+	dw := w * 2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			e := src[y*w+x]
+			for corner := 0; corner < 4; corner++ {
+				sx, sy := corner&1*2-1, corner>>1*2-1
+				p := func(u, v int) yuv {
+					px := min(max(x+u*sx, 0), w-1)
+					py := min(max(y+v*sy, 0), h-1)
+					return yuvs[py*w+px]
+				}
+				E, F, H, I := p(0, 0), p(1, 0), p(0, 1), p(1, 1)
+				B, C, D, G := p(0, -1), p(1, -1), p(-1, 0), p(-1, 1)
+				F4, I4, H5, I5 := p(2, 0), p(2, 1), p(0, 2), p(1, 2)
+
+				out := e
+				if E != F && E != H {
+					wd1 := distance(E, C) + distance(E, G) + distance(I, H5) + distance(I, F4) + 4*distance(H, F)
+					wd2 := distance(H, D) + distance(H, I5) + distance(F, I4) + distance(F, B) + 4*distance(E, I)
+					if wd1 < wd2 {
+						closer := at(src, w, h, x+sx, y)
+						if distance(E, F) > distance(E, H) {
+							closer = at(src, w, h, x, y+sy)
+						}
+						out = blend2(e, closer, 1, 1)
+					}
+				}
+				dst[(y*2+corner>>1)*dw+x*2+corner&1] = out
+			}
+		}
+	}
+}
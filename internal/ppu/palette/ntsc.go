@@ -0,0 +1,121 @@
+package palette
+
+import (
+	"image/color"
+	"math"
+)
+
+// NTSCParams tunes the generated NTSC palette.
+type NTSCParams struct {
+	Hue        float64 // Hue shift in degrees
+	Saturation float64 // 1.0 = unchanged
+	Contrast   float64 // 1.0 = unchanged
+	Brightness float64 // Added to luma, 0.0 = unchanged
+	Gamma      float64 // Display gamma, 2.2 = no correction
+}
+
+func DefaultNTSCParams() NTSCParams {
+	return NTSCParams{
+		Hue:        0,
+		Saturation: 1.0,
+		Contrast:   1.0,
+		Brightness: 0,
+		Gamma:      2.2,
+	}
+}
+
+// Composite signal voltages of the 2C02: low levels 0 ~ 3, high levels 0 ~ 3.
+var signalLevels = [8]float64{0.350, 0.518, 0.962, 1.550, 1.094, 1.506, 1.962, 1.962}
+
+const (
+	signalBlack = 0.518
+	signalWhite = 1.962
+
+	// Emphasis attenuates the signal during its color phases.
+	signalAttenuation = 0.746
+)
+
+// GenerateNTSC builds a 512-entry palette by modulating each color as the
+// PPU's composite signal and decoding it as an ideal NTSC TV does.
+func GenerateNTSC(params NTSCParams) *Palette {
+	var p Palette
+	for i := range p {
+		p[i] = ntscColor(i, params)
+	}
+	return &p
+}
+
+// The inColorPhase reports whether the square wave of a color is high
+// at the given phase (0 ~ 11, 12 phases per color subcarrier cycle).
+func inColorPhase(phase, colorVal int) bool {
+	return (colorVal+phase+8)%12 < 6
+}
+
+// Signal returns the composite signal of a 9-bit pixel index at the given phase,
+// normalized so that black is 0.0 and white is 1.0.
+func Signal(idx, phase int) float64 {
+	colorVal := idx & 0x0F
+	level := idx >> 4 & 3
+	emphasis := idx >> 6
+
+	// $xE and $xF output black.
+	if colorVal >= 0x0E {
+		level = 1
+	}
+	lo := signalLevels[level]
+	hi := signalLevels[level+4]
+	switch {
+	case colorVal == 0x00:
+		lo = hi
+	case colorVal >= 0x0D:
+		hi = lo
+	}
+
+	signal := lo
+	if inColorPhase(phase, colorVal) {
+		signal = hi
+	}
+	if (emphasis&1 != 0 && inColorPhase(phase, 0x0C)) ||
+		(emphasis&2 != 0 && inColorPhase(phase, 0x04)) ||
+		(emphasis&4 != 0 && inColorPhase(phase, 0x08)) {
+		signal *= signalAttenuation
+	}
+	return (signal - signalBlack) / (signalWhite - signalBlack)
+}
+
+// PhaseCos and PhaseSin return the subcarrier used to demodulate I and Q at a phase.
+func PhaseCos(phase float64) float64 { return math.Cos(math.Pi * phase / 6) }
+func PhaseSin(phase float64) float64 { return math.Sin(math.Pi * phase / 6) }
+
+func ntscColor(idx int, params NTSCParams) color.RGBA {
+	var y, i, q float64
+	for phase := 0; phase < 12; phase++ {
+		v := Signal(idx, phase) / 12
+		y += v
+		i += v * PhaseCos(float64(phase)+params.Hue/30)
+		q += v * PhaseSin(float64(phase)+params.Hue/30)
+	}
+
+	y = y*params.Contrast + params.Brightness
+	i *= params.Saturation
+	q *= params.Saturation
+	r, g, b := YIQToRGB(y, i, q)
+	return color.RGBA{GammaToByte(r, params.Gamma), GammaToByte(g, params.Gamma), GammaToByte(b, params.Gamma), 255}
+}
+
+// YIQToRGB converts a decoded NTSC color to linear RGB (0.0 ~ 1.0 before clamping).
+func YIQToRGB(y, i, q float64) (float64, float64, float64) {
+	r := y + 0.946882*i + 0.623557*q
+	g := y - 0.274788*i - 0.635691*q
+	b := y - 1.108545*i + 1.709007*q
+	return r, g, b
+}
+
+// GammaToByte converts a linear RGB channel to 8 bits for a display with the given gamma.
+func GammaToByte(v, gamma float64) byte {
+	if v <= 0 {
+		return 0
+	}
+	v = math.Pow(v, 2.2/gamma)
+	return byte(min(255, math.Round(v*255)))
+}
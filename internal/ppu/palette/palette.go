@@ -0,0 +1,97 @@
+package palette
+
+import (
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+)
+
+// Attenuation applied to the non-emphasized channels when a palette
+// has only 64 entries and the emphasis colors must be derived.
+const emphasisAttenuation = 0.816328
+
+//go:embed nes.pal
+var defaultPalFile []byte
+
+// Palette maps a 9-bit pixel index to RGBA.
+// Index bits 0 ~ 5 are the NES color, bits 6 ~ 8 are the PPUMASK
+// emphasis bits (red, green, blue on NTSC).
+type Palette [512]color.RGBA
+
+// Load builds a palette from the contents of a .pal file:
+// 64 entries (192 bytes) or 512 entries with emphasis (1536 bytes).
+func Load(data []byte) (*Palette, error) {
+	var p Palette
+	switch len(data) {
+	case 64 * 3:
+		for i := 0; i < 64; i++ {
+			p[i] = color.RGBA{data[i*3+0], data[i*3+1], data[i*3+2], 255}
+		}
+		p.deriveEmphasis()
+	case 512 * 3:
+		for i := 0; i < 512; i++ {
+			p[i] = color.RGBA{data[i*3+0], data[i*3+1], data[i*3+2], 255}
+		}
+	default:
+		return nil, fmt.Errorf("palette must have 64 or 512 entries (%d bytes given)", len(data))
+	}
+	return &p, nil
+}
+
+// Default returns the built-in palette.
+func Default() *Palette {
+	p, err := Load(defaultPalFile)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// LoadFile reads a .pal file. See Load().
+func LoadFile(path string) (*Palette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
+
+// deriveEmphasis fills entries 64 ~ 511 from the first 64 colors.
+// Each emphasis bit darkens the two other channels.
+// Columns $xE and $xF are black and are left unchanged.
+func (p *Palette) deriveEmphasis() {
+	for emphasis := 1; emphasis < 8; emphasis++ {
+		for i := 0; i < 64; i++ {
+			c := p[i]
+			if i&0x0E != 0x0E {
+				r, g, b := float64(c.R), float64(c.G), float64(c.B)
+				if emphasis&1 != 0 { // red
+					g *= emphasisAttenuation
+					b *= emphasisAttenuation
+				}
+				if emphasis&2 != 0 { // green
+					r *= emphasisAttenuation
+					b *= emphasisAttenuation
+				}
+				if emphasis&4 != 0 { // blue
+					r *= emphasisAttenuation
+					g *= emphasisAttenuation
+				}
+				c = color.RGBA{byte(r), byte(g), byte(b), 255}
+			}
+			p[emphasis<<6|i] = c
+		}
+	}
+}
+
+// ToRGBA converts pixel indices into dst, which must be as wide as src rows
+// (256x240 for a PPU frame) and start at (0, 0).
+func (p *Palette) ToRGBA(dst *image.RGBA, src []uint16) {
+	pix := dst.Pix[:len(src)*4]
+	for i, idx := range src {
+		c := p[idx&0x1FF]
+		*(*[4]byte)(pix[i*4:]) = [4]byte{c.R, c.G, c.B, c.A}
+	}
+}
@@ -2,29 +2,58 @@ package ppu
 
 import (
 	"fmt"
-	"image"
-	"image/color"
-	"log"
 	"nesutaro/internal/ppu/bus"
-	"os"
+	"nesutaro/internal/region"
 )
 
 const (
-	VblankFlag      byte = 1 << 7
-	VblankNMIEnable byte = 1 << 7
+	VblankFlag         byte = 1 << 7
+	Sprite0HitFlag     byte = 1 << 6
+	SpriteOverflowFlag byte = 1 << 5
+	VblankNMIEnable    byte = 1 << 7
 )
 
+// The PPU finds at most 8 sprites per line (secondary OAM is 32 bytes).
+const MaxSpritesPerLine = 8
+
+// Bits of the I/O latch (open bus) fade to 0 about 600ms after they were last driven.
+const ioLatchDecayFrames = 36
+
+const DotsPerLine = 341
+
 var xFlipLUT [256]byte
 
+func init() {
+	for i := range xFlipLUT {
+		var flipped byte
+		for b := 0; b < 8; b++ {
+			flipped |= byte(i) >> b & 1 << (7 - b)
+		}
+		xFlipLUT[i] = flipped
+	}
+}
+
+// A sprite found by sprite evaluation.
+// Lo/Hi are its pattern bits for the line, already X-flipped.
+type sprite struct {
+	Y, Tile, Attr, X byte
+	Lo, Hi           byte
+}
+
 type PPU struct {
 	Bus      *bus.Bus
-	viewport [256 * 240]int
-	screen   [2]*image.RGBA
+	viewport [2][256 * 240]uint16 // colorIndex | emphasis<<6 of each pixel
 	front    int
 
 	oam [256]byte
 
-	cycles int
+	// Position of the next dot
+	dot        int // 0 ~ 340
+	ly         int // 0 ~ 261 (NTSC), 0 ~ 311 (PAL, Dendy)
+	isOddFrame bool
+	dotPhase   int // Remainder of region.Timing.PPUDotsPer5Cycles / 5 dots
+
+	timing region.Timing
 
 	v         uint16
 	t         uint16
@@ -35,235 +64,519 @@ type PPU struct {
 	ppustatus byte
 	oamaddr   byte
 
-	hasNMI bool
-
-	ly int
-
-	nesPal [64]color.RGBA
+	// NMI is raised on the rising edge of (VBlank flag && NMI enable).
+	nmiOutput          bool
+	hasNMI             bool
+	isVBlankSuppressed bool // PPUSTATUS was read just before VBlank
+
+	// PPUDATA reads are delayed by one read through this buffer.
+	readBuffer byte
+
+	// The I/O latch holds the last value driven on the CPU-PPU data bus.
+	// Reading write-only registers returns it (PPU open bus).
+	ioLatch      byte
+	ioLatchDecay [8]int // Frames left before each bit fades
+
+	// BG fetch latches and shift registers
+	ntByte    byte
+	atByte    byte
+	bgLo      byte
+	bgHi      byte
+	bgShiftLo uint16
+	bgShiftHi uint16
+	atShiftLo uint16
+	atShiftHi uint16
+
+	// Sprites found on this line (for the next line),
+	// and sprites fetched for the current line.
+	evalSprites     [64]sprite
+	evalSpriteCount int
+	lineSprites     [64]sprite
+	lineSpriteCount int
+
+	// Whether slot 0 holds OAM sprite 0
+	isSprite0InEval bool
+	isSprite0OnLine bool
+
+	// Sprite evaluation progress: OAM entry n, byte m
+	evalN    int
+	evalM    int
+	evalWait int // Dots left to copy the found sprite
+	evalDone bool
+
+	isBackdropFilled bool // The rest of the current line is already the backdrop.
+
+	isSpriteLimitDisabled bool // From config.toml
 }
 
 func NewPPU(b *bus.Bus) *PPU {
-	palFilePath := "nes.pal"
-	palFile, err := os.ReadFile(palFilePath)
-	if err != nil {
-		log.Fatal(err)
-	}
 	p := &PPU{
-		Bus: b,
-	}
-	p.screen[0] = image.NewRGBA(image.Rect(0, 0, 256, 240))
-	p.screen[1] = image.NewRGBA(image.Rect(0, 0, 256, 240))
-
-	for i := 0; i < 64; i++ {
-		p.nesPal[i] = color.RGBA{palFile[i*3+0], palFile[i*3+1], palFile[i*3+2], 255}
+		Bus:    b,
+		timing: region.NTSC.Timing(),
 	}
 	return p
 }
 
+// The region sets the number of lines and dots per CPU cycle.
+func (p *PPU) SetRegion(r region.Region) {
+	p.timing = r.Timing()
+}
+
+// From config.toml
+// When the limit is disabled, every sprite on a line is drawn (no flicker).
+// The sprite overflow flag still behaves as on the hardware.
+func (p *PPU) SetIsSpriteLimitDisabled(b bool) {
+	p.isSpriteLimitDisabled = b
+}
+
+func (p *PPU) IsSpriteLimitDisabled() bool {
+	return p.isSpriteLimitDisabled
+}
+
+// The Step runs 3 dots per CPU cycle (3.2 on PAL: 16 dots per 5 cycles).
 func (p *PPU) Step(cpuCycles int) {
-	p.cycles += cpuCycles * 3
-	for p.cycles >= 341 {
-		isBGEnabled := p.ppumask>>3&1 == 1
-		switch {
-		case p.ly < 240: // HBlank: 0 ~ 239
-			if isBGEnabled {
-				p.drawBGLine()
-
-				// dot 256
-				if p.v&0x7000 != 0x7000 {
-					p.v += 0x1000 // fineY++
-				} else {
-					p.v &^= 0x7000 // fineY = 0
-					coarseY := p.v & 0x03E0 >> 5
-					switch coarseY {
-					case 29:
-						coarseY = 0
-						p.v ^= 0x0800 // tableY reverse
-					case 31:
-						coarseY = 0
-					default:
-						coarseY += 1
-					}
-					p.v = p.v&^0x03E0 + coarseY<<5
-				}
-				p.v = p.v&^0x041F + p.t&0x041F // p.v = p.t (coarseX, tableX only) dot 257
-			}
-			if p.ly > 0 {
-				isSpriteEnabled := p.ppumask>>4&1 == 1
-				if isSpriteEnabled {
-					p.drawSpritesLine(p.ly - 1)
-				}
-			}
+	for i := 0; i < cpuCycles; i++ {
+		p.dotPhase += p.timing.PPUDotsPer5Cycles
+		for ; p.dotPhase >= 5; p.dotPhase -= 5 {
+			p.tick()
+		}
+	}
+}
 
-		case p.ly == 240: // VBlank: 240 ~ 259
-			p.front ^= 1
-			backColor := p.nesPal[p.Bus.Read(0x3F00)]
-			for y := 0; y < 240; y++ {
-				for x := 0; x < 256; x++ {
-					p.screen[p.front^1].SetRGBA(x, y, backColor)
-				}
-			}
-			p.ppustatus |= VblankFlag
-			if p.ppuctrl&VblankNMIEnable != 0 {
-				p.hasNMI = true
+// Scanlines:
+// 0 ~ 239: Visible, 240: Post-render, 241 ~ 260: VBlank, 261: Pre-render (NTSC)
+// PAL has 70 VBlank lines (241 ~ 310), Dendy 51 post-render lines (240 ~ 290)
+// and 20 VBlank lines (291 ~ 310). Their pre-render line is 311.
+func (p *PPU) tick() {
+	isRendering := p.ppumask&0x18 != 0
+	isVisibleLine := p.ly < 240
+	isPreRenderLine := p.ly == p.timing.LinesPerFrame-1
+
+	if isRendering && (isVisibleLine || isPreRenderLine) {
+		p.stepBG(isPreRenderLine)
+		p.stepSprites(isVisibleLine)
+	}
+	if isVisibleLine && 1 <= p.dot && p.dot <= 256 {
+		// While rendering is off, the line is filled with the backdrop at once.
+		// Pixels are drawn one by one again from the dot anything changes it.
+		if p.dot == 1 {
+			p.isBackdropFilled = !isRendering && p.v&0x3F00 != 0x3F00
+			if p.isBackdropFilled {
+				p.fillBackdrop(p.ly)
 			}
-		case p.ly == 260: // Post(pre) render scanline: 260, 261
-			p.ppustatus &^= VblankFlag
-			p.v = p.v&^0x7BE0 + p.t&0x7BE0 // p.v = p.t (fineY, coarseY, tableY only) dot 280 ~ 304
 		}
+		if isRendering || p.v&0x3F00 == 0x3F00 {
+			p.isBackdropFilled = false
+		}
+		if !p.isBackdropFilled {
+			p.renderPixel(isRendering)
+		}
+	}
 
-		p.cycles -= 341
+	switch {
+	case p.ly == p.timing.VBlankLine && p.dot == 1:
+		p.decayIOLatch()
+		p.front ^= 1
+		if !p.isVBlankSuppressed {
+			p.ppustatus |= VblankFlag
+		}
+		p.isVBlankSuppressed = false
+		p.updateNMI()
+	case isPreRenderLine && p.dot == 1:
+		p.ppustatus &^= VblankFlag | Sprite0HitFlag | SpriteOverflowFlag
+		p.updateNMI()
+	}
 
+	p.dot += 1
+	// On odd frames, the last dot of the pre-render line is skipped (NTSC only).
+	if isPreRenderLine && p.dot == 340 && p.isOddFrame && isRendering && p.timing.IsOddFrameSkipping {
+		p.dot = DotsPerLine
+	}
+	if p.dot >= DotsPerLine {
+		p.dot = 0
 		p.ly += 1
-		if p.ly >= 262 {
+		if p.ly >= p.timing.LinesPerFrame {
 			p.ly = 0
+			p.isOddFrame = !p.isOddFrame
 		}
-
 	}
 }
 
 // ============================================ BG =================================================
 
-func (p *PPU) drawBGLine() {
-	prevCoarseX := -1
-	//p.x := int(p.x & 7)
+// Each tile takes 8 dots: NT byte (1, 2), AT byte (3, 4), pattern low (5, 6), pattern high (7, 8).
+// Tiles are fetched at dots 1 ~ 256 and the first two of the next line at 321 ~ 336.
+func (p *PPU) stepBG(isPreRenderLine bool) {
+	dot := p.dot
+	if (2 <= dot && dot <= 257) || (322 <= dot && dot <= 337) {
+		p.shiftBG()
+		if dot&7 == 1 { // 9, 17, ..., 257, 329, 337
+			p.loadBGShifters()
+		}
+	}
+	if (1 <= dot && dot <= 256) || (321 <= dot && dot <= 336) {
+		switch dot & 7 {
+		case 1:
+			p.ntByte = p.Bus.Read(0x2000 | p.v&0x0FFF)
+		case 3:
+			attrAddr := 0x23C0 | p.v&0x0C00 | p.v>>4&0x38 | p.v>>2&0x07
+			shift := p.v>>4&0x04 | p.v&0x02 // = (coarseY & 2) << 1 | coarseX & 2
+			p.atByte = p.Bus.Read(attrAddr) >> shift & 3
+		case 5:
+			p.bgLo = p.Bus.Read(p.bgPatternAddr())
+		case 7:
+			p.bgHi = p.Bus.Read(p.bgPatternAddr() + 8)
+		case 0:
+			p.incrementCoarseX()
+		}
+	}
+	switch {
+	case dot == 256:
+		p.incrementY()
+	case dot == 257:
+		p.v = p.v&^0x041F | p.t&0x041F // p.v = p.t (coarseX, tableX only)
+	case isPreRenderLine && 280 <= dot && dot <= 304:
+		p.v = p.v&^0x7BE0 | p.t&0x7BE0 // p.v = p.t (fineY, coarseY, tableY only)
+	}
+}
+
+func (p *PPU) bgPatternAddr() uint16 {
+	base := uint16(p.ppuctrl>>4&1) << 12
+	fineY := p.v & 0x7000 >> 12
+	return base + uint16(p.ntByte)<<4 + fineY
+}
 
-	var tileLine [2]byte
-	startX := int((p.ppumask>>1&1 ^ 1) * 8)
-	for i := 0; i < 256; i++ {
-		if i >= startX {
-			currentCoarseX := int(p.v & 0x001F)
+func (p *PPU) shiftBG() {
+	p.bgShiftLo <<= 1
+	p.bgShiftHi <<= 1
+	p.atShiftLo <<= 1
+	p.atShiftHi <<= 1
+}
 
-			if currentCoarseX != prevCoarseX {
-				nameAddr := uint16(0x2000) + p.v&0x0FFF
-				tileIndex := p.Bus.Read(nameAddr)
-				tileLine = p.getBGTileLine(tileIndex)
-			}
-			raw := p.getTileLinePixel(&tileLine, int(p.x))
-			if raw != 0 {
-				rgba := p.resolveBGPalette(raw)
-				p.setPixel(i, p.ly, rgba)
+func (p *PPU) loadBGShifters() {
+	p.bgShiftLo = p.bgShiftLo&0xFF00 | uint16(p.bgLo)
+	p.bgShiftHi = p.bgShiftHi&0xFF00 | uint16(p.bgHi)
+	p.atShiftLo = p.atShiftLo&0xFF00 | uint16(p.atByte&1)*0xFF
+	p.atShiftHi = p.atShiftHi&0xFF00 | uint16(p.atByte>>1&1)*0xFF
+}
+
+func (p *PPU) incrementCoarseX() {
+	if p.v&0x001F == 31 {
+		p.v &^= 0x001F
+		p.v ^= 0x0400 // tableX reverse
+	} else {
+		p.v += 1
+	}
+}
+
+func (p *PPU) incrementY() {
+	if p.v&0x7000 != 0x7000 {
+		p.v += 0x1000 // fineY++
+		return
+	}
+	p.v &^= 0x7000 // fineY = 0
+	coarseY := p.v & 0x03E0 >> 5
+	switch coarseY {
+	case 29:
+		coarseY = 0
+		p.v ^= 0x0800 // tableY reverse
+	case 31:
+		coarseY = 0
+	default:
+		coarseY += 1
+	}
+	p.v = p.v&^0x03E0 | coarseY<<5
+}
+
+// ========================================= Sprites ===============================================
+
+// Dots 1 ~ 64: Secondary OAM clear
+// Dots 65 ~ 256: Sprite evaluation for the next line
+// Dots 257 ~ 320: Sprite pattern fetches (8 dots per sprite)
+func (p *PPU) stepSprites(isVisibleLine bool) {
+	dot := p.dot
+	switch {
+	case dot == 64:
+		p.evalSpriteCount = 0
+		p.isSprite0InEval = false
+		p.evalN = 0
+		p.evalM = 0
+		p.evalWait = 0
+		p.evalDone = false
+	case isVisibleLine && 65 <= dot && dot <= 256:
+		if p.evalWait > 0 {
+			p.evalWait -= 1
+		} else if dot&1 == 1 && !p.evalDone {
+			p.evaluateSprite()
+		}
+		if dot == 256 && p.isSpriteLimitDisabled {
+			p.findAllSprites()
+		}
+	case 257 <= dot && dot <= 320:
+		p.oamaddr = 0
+		if (dot-257)&7 == 7 {
+			p.fetchSprite((dot - 257) / 8)
+		}
+		if dot == 320 {
+			for i := 8; i < p.evalSpriteCount; i++ {
+				p.fetchSprite(i)
 			}
-			prevCoarseX = currentCoarseX
+			p.lineSpriteCount = p.evalSpriteCount
+			p.isSprite0OnLine = p.isSprite0InEval
 		}
+	}
+}
+
+func (p *PPU) spriteHeight() int {
+	return 8 * int(1+p.ppuctrl>>5&1)
+}
 
-		if p.x&7 != 7 {
-			p.x += 1
-		} else {
-			p.x = 0
-			coarseX := p.v & 0x001F
-			if coarseX == 31 {
-				coarseX = 0
-				p.v ^= 0x0400
-			} else {
-				coarseX += 1
+func (p *PPU) isSpriteInRange(posY byte) bool {
+	row := p.ly - int(posY)
+	return 0 <= row && row < p.spriteHeight()
+}
+
+// The evaluateSprite runs one OAM read of sprite evaluation (every 2 dots).
+// After 8 sprites are found, the PPU keeps looking for a 9th one to set the
+// overflow flag, but it wrongly increments m along with n, so it reads tile,
+// attribute and X bytes as Y coordinates (the hardware overflow bug).
+func (p *PPU) evaluateSprite() {
+	n := p.evalN
+	if p.evalSpriteCount < MaxSpritesPerLine {
+		if p.isSpriteInRange(p.oam[n<<2+0]) {
+			if n == 0 {
+				p.isSprite0InEval = true
 			}
-			p.v = p.v&^0x001F + coarseX
+			p.evalSprites[p.evalSpriteCount] = p.oamSprite(n)
+			p.evalSpriteCount += 1
+			p.evalWait = 6 // Copying 3 more bytes takes 6 dots.
+		}
+		p.evalN += 1
+	} else {
+		if p.isSpriteInRange(p.oam[n<<2+p.evalM]) {
+			p.ppustatus |= SpriteOverflowFlag
+			p.evalDone = true
+			return
 		}
+		p.evalN += 1
+		p.evalM = (p.evalM + 1) & 3 // Hardware bug
+	}
+	if p.evalN >= 64 {
+		p.evalDone = true
 	}
 }
-func (p *PPU) getBGTileLine(tileIndex byte) [2]byte {
-	base := uint16(0x0000)
-	if p.ppuctrl>>4&1 == 1 {
-		base = 0x1000
+
+// Without the sprite limit, every in-range sprite is used, in OAM order.
+// The first 8 are the same ones the evaluation has found.
+func (p *PPU) findAllSprites() {
+	p.evalSpriteCount = 0
+	for n := 0; n < 64; n++ {
+		if p.isSpriteInRange(p.oam[n<<2+0]) {
+			p.evalSprites[p.evalSpriteCount] = p.oamSprite(n)
+			p.evalSpriteCount += 1
+		}
 	}
-	fineY := p.v & 0x7000 >> 12
-	var data [2]byte
-	data[0] = p.Bus.Read(base + uint16(tileIndex)<<4 + (0 << 3) + fineY)
-	data[1] = p.Bus.Read(base + uint16(tileIndex)<<4 + (1 << 3) + fineY)
-	return data
 }
-func (p *PPU) resolveBGPalette(raw int) color.RGBA {
-	blockX := p.v & 0x001C >> 2 // = coarseX / 4
-	blockY := p.v & 0x0380 >> 7 // = coarseY / 4
-	attrAddr := uint16(0x23C0) + p.v&0x0C00 + blockY<<3 + blockX
-	attr := p.Bus.Read(attrAddr)
 
-	shift := p.v&0x0040>>4 + p.v&0x0002
+func (p *PPU) oamSprite(n int) sprite {
+	return sprite{
+		Y:    p.oam[n<<2+0],
+		Tile: p.oam[n<<2+1],
+		Attr: p.oam[n<<2+2],
+		X:    p.oam[n<<2+3],
+	}
+}
+
+// Empty slots still fetch tile $FF, as the PPU does.
+func (p *PPU) fetchSprite(slot int) {
+	if slot >= p.evalSpriteCount {
+		addr := p.spritePatternAddr(0xFF, 0)
+		p.Bus.Read(addr)
+		p.Bus.Read(addr + 8)
+		return
+	}
+	s := p.evalSprites[slot]
+	row := p.ly - int(s.Y)
+	isYFlip := s.Attr>>7&1 == 1
+	if isYFlip {
+		row = p.spriteHeight() - 1 - row
+	}
+	addr := p.spritePatternAddr(s.Tile, row)
+	s.Lo = p.Bus.Read(addr)
+	s.Hi = p.Bus.Read(addr + 8)
+	isXFlip := s.Attr>>6&1 == 1
+	if isXFlip {
+		s.Lo = xFlipLUT[s.Lo]
+		s.Hi = xFlipLUT[s.Hi]
+	}
+	p.lineSprites[slot] = s
+}
+
+func (p *PPU) spritePatternAddr(tileIdx byte, row int) uint16 {
+	idx := uint16(tileIdx)
+	if p.spriteHeight() == 16 {
+		// 8x16: the bank is selected by bit 0, rows 8 ~ 15 are in the next tile.
+		start := idx&1<<12 + idx&0xFE<<4
+		if row >= 8 {
+			row += 8
+		}
+		return start + uint16(row)
+	}
+	spriteAddrNum := uint16(p.ppuctrl >> 3 & 1)
+	return spriteAddrNum<<12 + idx<<4 + uint16(row)
+}
+
+// ======================================== BG/Sprites =============================================
 
-	palVal := uint16(attr >> shift & 3)
-	colorVal := p.Bus.Read(0x3F00 + palVal<<2 + uint16(raw))
-	return p.nesPal[colorVal&0x3F]
+func (p *PPU) renderPixel(isRendering bool) {
+	x := p.dot - 1
+	bgPixel, bgPal := p.bgPixelAt(x)
+	spPixel, spPal, isBehindBG := p.spritePixelAt(x, bgPixel)
+
+	var addr uint16
+	switch {
+	case isRendering:
+		addr = composite(bgPixel, bgPal, spPixel, spPal, isBehindBG)
+	case p.v&0x3F00 == 0x3F00:
+		// While rendering is off, the backdrop is the palette entry v points to.
+		addr = p.v
+	default:
+		addr = 0x3F00
+	}
+	p.setPixel(x, p.ly, p.Bus.Read(addr)&0x3F)
 }
 
-// ========================================= Sprites ===============================================
+// The bgPixelAt returns the BG pixel value (0 = transparent) and palette number.
+func (p *PPU) bgPixelAt(x int) (byte, byte) {
+	isBGEnabled := p.ppumask>>3&1 == 1
+	isClipped := x < 8 && p.ppumask>>1&1 == 0
+	if !isBGEnabled || isClipped {
+		return 0, 0
+	}
+	shift := 15 - p.x
+	pixel := byte(p.bgShiftHi>>shift&1)<<1 | byte(p.bgShiftLo>>shift&1)
+	pal := byte(p.atShiftHi>>shift&1)<<1 | byte(p.atShiftLo>>shift&1)
+	return pixel, pal
+}
 
-func (p *PPU) drawSpritesLine(ly int) {
-	tileH := 8 * int(1+p.ppuctrl>>5&1)
-	var tileLine [2]byte
-	startX := int(p.ppumask>>2&1^1) * 8
-	for i := 63; i >= 0; i-- {
-		posY := int(p.oam[i<<2+0])
-		tileIdx := p.oam[i<<2+1]
-		attr := int(p.oam[i<<2+2])
-		posX := int(p.oam[i<<2+3])
-
-		isXFlip := attr>>6&1 == 1
-		isYFlip := attr>>7&1 == 1
-
-		if posY <= ly && ly < posY+tileH {
-			fineY := ly - posY
-			tileY := fineY
-			if isYFlip {
-				tileY = tileH - 1 - tileY
-			}
-			tileLine = p.getSpriteTileLine(tileIdx, tileY)
-			for j := 0; j < 8; j++ {
-				if startX <= posX+j && posX+j < 256 {
-					tileX := j
-					if isXFlip {
-						tileX = 7 - tileX
-					}
-					raw := p.getTileLinePixel(&tileLine, tileX)
-					palVal := attr & 3
-					if raw != 0 {
-						rgba := p.resolveSpritesPalette(palVal, raw)
-						p.setPixel(posX+j, ly+1, rgba)
-					}
-					p.ppustatus |= 1 << 6
-				}
-			}
+// The spritePixelAt returns the front-most sprite pixel (0 = transparent),
+// its palette number (4 ~ 7) and priority (attribute bit 5: behind BG).
+// The lowest OAM index with an opaque pixel wins, whatever its priority.
+// It also sets the sprite 0 hit flag.
+func (p *PPU) spritePixelAt(x int, bgPixel byte) (byte, byte, bool) {
+	isSpriteEnabled := p.ppumask>>4&1 == 1
+	isClipped := x < 8 && p.ppumask>>2&1 == 0
+	if !isSpriteEnabled || isClipped {
+		return 0, 0, false
+	}
+	for i := 0; i < p.lineSpriteCount; i++ {
+		s := &p.lineSprites[i]
+		col := x - int(s.X)
+		if col < 0 || 8 <= col {
+			continue
 		}
+		shift := 7 - col
+		pixel := s.Hi>>shift&1<<1 | s.Lo>>shift&1
+		if pixel == 0 {
+			continue
+		}
+		// Sprite 0 hit: an opaque pixel of sprite 0 overlaps an opaque BG pixel.
+		// Clipped pixels (left 8) are already transparent here. Never at x = 255.
+		if i == 0 && p.isSprite0OnLine && bgPixel != 0 && x != 255 {
+			p.ppustatus |= Sprite0HitFlag
+		}
+		return pixel, 4 + s.Attr&3, s.Attr>>5&1 == 1
 	}
+	return 0, 0, false
 }
-func (p *PPU) getSpriteTileLine(tileIndex byte, fineY int) [2]byte {
-	idx := uint16(tileIndex)
-	var start uint16
 
-	tileH := 8 * int(1+p.ppuctrl>>5&1)
-	if tileH == 16 {
-		start = idx&1<<12 + idx&0xFE<<4
-	} else {
-		spriteAddrNum := uint16(p.ppuctrl >> 3 & 1)
-		start = spriteAddrNum<<12 + idx<<4
+// The composite returns the palette address of the output pixel.
+// A sprite behind the BG is still the front-most sprite, so it also hides
+// the later sprites under an opaque BG pixel (e.g. SMB3 items rising out of blocks).
+func composite(bgPixel, bgPal, spPixel, spPal byte, isBehindBG bool) uint16 {
+	switch {
+	case spPixel != 0 && (bgPixel == 0 || !isBehindBG):
+		return 0x3F00 | uint16(spPal)<<2 | uint16(spPixel)
+	case bgPixel != 0:
+		return 0x3F00 | uint16(bgPal)<<2 | uint16(bgPixel)
+	default:
+		return 0x3F00
 	}
+}
 
-	var data [2]byte
-	data[0] = p.Bus.Read(start + (0 << 3) + uint16(fineY))
-	data[1] = p.Bus.Read(start + (1 << 3) + uint16(fineY))
-	return data
+func (p *PPU) setPixel(x, y int, colorIdx byte) {
+	p.viewport[p.front^1][y*256+x] = p.pixelIndex(colorIdx)
 }
-func (p *PPU) resolveSpritesPalette(palVal int, raw int) color.RGBA {
-	colorVal := p.Bus.Read(0x3F10 + uint16(palVal)<<2 + uint16(raw))
-	return p.nesPal[colorVal&0x3F]
+
+// The pixelIndex applies PPUMASK greyscale (bit 0) and emphasis (bits 5 ~ 7)
+// to the colorIndex, as the PPU does on its video output.
+func (p *PPU) pixelIndex(colorIdx byte) uint16 {
+	if p.ppumask&1 == 1 {
+		colorIdx &= 0x30
+	}
+	emphasis := p.ppumask >> 5
+	if p.timing.IsEmphasisSwapped {
+		// Stored in NTSC order (red, green, blue) for the palettes.
+		emphasis = emphasis&4 | emphasis&1<<1 | emphasis>>1&1
+	}
+	return uint16(colorIdx) | uint16(emphasis)<<6
 }
 
-// ======================================== BG/Sprites =============================================
+// The fillBackdrop sets a whole line to the backdrop color,
+// doubling the copied span each time like memset.
+func (p *PPU) fillBackdrop(y int) {
+	line := p.viewport[p.front^1][y*256 : (y+1)*256]
+	line[0] = p.pixelIndex(p.Bus.Read(0x3F00) & 0x3F)
+	for n := 1; n < len(line); n *= 2 {
+		copy(line[n:], line[:n])
+	}
+}
+
+// Get Viewport pixels as 9-bit indices (colorIndex | emphasis<<6), row by row.
+// They are converted to RGBA by a palette.Palette.
+func (p *PPU) GetViewport() *[256 * 240]uint16 {
+	return &p.viewport[p.front]
+}
 
-func (p *PPU) getTileLinePixel(tileLine *[2]byte, fineX int) int {
-	shift := 7 - fineX
-	lo := tileLine[0] & (1 << shift) >> shift
-	hi := tileLine[1] & (1 << shift) >> shift
-	px := int(hi<<1 | lo)
-	return px & 0x03
+// Get the frame being drawn, in the format of GetViewport().
+// Only the pixels the beam has passed (see GetBeam) belong to the current frame.
+func (p *PPU) GetBackViewport() *[256 * 240]uint16 {
+	return &p.viewport[p.front^1]
 }
-func (p *PPU) setPixel(x, y int, rgba color.RGBA) {
-	p.screen[p.front^1].SetRGBA(x, y, rgba)
+
+// Get the position of the next dot. Dot x+1 of a visible line outputs pixel x.
+func (p *PPU) GetBeam() (dot, line int) {
+	return p.dot, p.ly
 }
 
-// Get Viewport pixels converted from colorIndex to RGBA
-func (p *PPU) GetGameScreen() *image.RGBA {
-	return p.screen[p.front]
+// ======================================== I/O Registers ==========================================
+
+// The refreshIOLatch drives the bits of mask with val.
+func (p *PPU) refreshIOLatch(val, mask byte) {
+	p.ioLatch = p.ioLatch&^mask | val&mask
+	for i := 0; i < 8; i++ {
+		if mask>>i&1 == 1 {
+			p.ioLatchDecay[i] = ioLatchDecayFrames
+		}
+	}
+}
+
+// The decayIOLatch is called once per frame.
+func (p *PPU) decayIOLatch() {
+	for i := 0; i < 8; i++ {
+		if p.ioLatchDecay[i] > 0 {
+			p.ioLatchDecay[i] -= 1
+			if p.ioLatchDecay[i] == 0 {
+				p.ioLatch &^= 1 << i
+			}
+		}
+	}
+}
+
+// ReadIOLatch is used for reads of the write-only registers
+// ($2000, $2001, $2003, $2005, $2006).
+func (p *PPU) ReadIOLatch() byte {
+	return p.ioLatch
 }
 
 func (p *PPU) ReadOAM(addr uint16) byte {
@@ -273,36 +586,64 @@ func (p *PPU) WriteOAM(addr uint16, val byte) {
 	p.oam[addr] = val
 }
 
+// Enabling NMI while the VBlank flag is set raises an NMI right away.
 func (p *PPU) WritePPUCTRL(val byte) {
+	p.refreshIOLatch(val, 0xFF)
 	p.ppuctrl = val
 	p.t = p.t&0x73FF | uint16(val)&0x03<<10
+	p.updateNMI()
 }
 
 func (p *PPU) WritePPUMASK(val byte) {
+	p.refreshIOLatch(val, 0xFF)
 	p.ppumask = val
+	p.isBackdropFilled = false
 }
 
+// Bits 0 ~ 4 of PPUSTATUS are open bus.
+// Reading it clears the VBlank flag. A read right at the start of VBlank
+// races with the flag being set:
+//   - 1 dot before: the flag reads 0 and is not set this frame (no NMI).
+//   - On the dot or 1 dot after: the flag reads 1, but the NMI is cancelled.
 func (p *PPU) ReadPPUSTATUS() byte {
 	p.w = false
-	val := p.ppustatus
-	//p.ppustatus &^= VblankFlag
+	val := p.ppustatus&0xE0 | p.ioLatch&0x1F
+	p.refreshIOLatch(val, 0xE0)
+	p.ppustatus &^= VblankFlag
+	if p.ly == p.timing.VBlankLine {
+		switch p.dot {
+		case 1: // The next dot would set the flag.
+			p.isVBlankSuppressed = true
+		case 2, 3:
+			p.hasNMI = false
+		}
+	}
+	p.updateNMI()
 	return val
 }
 
 func (p *PPU) WriteOAMADDR(val byte) {
+	p.refreshIOLatch(val, 0xFF)
 	p.oamaddr = val
 }
 
 func (p *PPU) ReadOAMDATA() byte {
-	return p.oam[p.oamaddr]
+	val := p.oam[p.oamaddr]
+	if p.oamaddr&3 == 2 {
+		val &= 0xE3 // Bits 2 ~ 4 of sprite attributes do not exist.
+	}
+	p.refreshIOLatch(val, 0xFF)
+	return val
 }
 
 func (p *PPU) WriteOAMDATA(val byte) {
+	p.refreshIOLatch(val, 0xFF)
 	p.oam[p.oamaddr] = val
 	p.oamaddr += 1
 }
 
 func (p *PPU) WritePPUSCROLL(val byte) {
+	p.refreshIOLatch(val, 0xFF)
 	isFirstWriting := !p.w
 	if isFirstWriting {
 		coarseX := uint16(val >> 3)
@@ -317,6 +658,7 @@ func (p *PPU) WritePPUSCROLL(val byte) {
 }
 
 func (p *PPU) WritePPUADDR(val byte) {
+	p.refreshIOLatch(val, 0xFF)
 	isFirstWriting := !p.w
 	if isFirstWriting {
 		// Bit 14 is forced 0 when writting the PPUADDR high byte.
@@ -328,8 +670,21 @@ func (p *PPU) WritePPUADDR(val byte) {
 	p.w = !p.w
 }
 
+// Reads return the internal buffer, then refill it from v.
+// Palette reads return the palette immediately (bits 6 ~ 7 are open bus),
+// while the buffer is filled with the nametable byte "under" the palette.
 func (p *PPU) ReadPPUDATA() byte {
-	val := p.Bus.Read(p.v)
+	addr := p.v & 0x3FFF
+	var val byte
+	if addr >= 0x3F00 {
+		val = p.Bus.Read(addr)&0x3F | p.ioLatch&0xC0
+		p.readBuffer = p.Bus.Read(addr - 0x1000)
+		p.refreshIOLatch(val, 0x3F)
+	} else {
+		val = p.readBuffer
+		p.readBuffer = p.Bus.Read(addr)
+		p.refreshIOLatch(val, 0xFF)
+	}
 	if p.ppuctrl>>2&1 == 0 {
 		p.v += 1
 	} else {
@@ -339,7 +694,11 @@ func (p *PPU) ReadPPUDATA() byte {
 }
 
 func (p *PPU) WritePPUDATA(val byte) {
-	p.Bus.Write(p.v, val)
+	p.refreshIOLatch(val, 0xFF)
+	if p.v&0x3F00 == 0x3F00 {
+		p.isBackdropFilled = false
+	}
+	p.Bus.Write(p.v&0x3FFF, val)
 	if p.ppuctrl>>2&1 == 0 {
 		p.v += 1
 	} else {
@@ -347,8 +706,13 @@ func (p *PPU) WritePPUDATA(val byte) {
 	}
 }
 
-func (p *PPU) WriteOAMDMA(data *[0x100]byte) {
-	p.oam = *data
+// The updateNMI detects the rising edge of the NMI output.
+func (p *PPU) updateNMI() {
+	output := p.ppustatus&VblankFlag != 0 && p.ppuctrl&VblankNMIEnable != 0
+	if output && !p.nmiOutput {
+		p.hasNMI = true
+	}
+	p.nmiOutput = output
 }
 
 func (p *PPU) HasNMI() bool {
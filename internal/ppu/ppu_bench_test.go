@@ -0,0 +1,43 @@
+package ppu
+
+import (
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/ppu/bus"
+	"testing"
+)
+
+// newBenchPPU returns a PPU rendering a screen full of background tiles
+// and 64 sprites, 8 on each line they cover.
+func newBenchPPU() *PPU {
+	rom := make([]byte, 0x10+0x4000+0x2000)
+	copy(rom, "NES\x1a\x01\x01")
+	chr := rom[0x10+0x4000:]
+	for i := range chr {
+		chr[i] = byte(i * 37)
+	}
+	p := NewPPU(bus.NewBus(cartridge.NewCartridge(rom)))
+	for addr := uint16(0x2000); addr < 0x2400; addr++ {
+		p.Bus.Write(addr, byte(addr))
+	}
+	for addr := uint16(0x3F00); addr < 0x3F20; addr++ {
+		p.Bus.Write(addr, byte(addr)&0x3F)
+	}
+	for n := uint16(0); n < 64; n++ {
+		p.WriteOAM(n*4+0, byte(n/8*24))   // Y
+		p.WriteOAM(n*4+1, byte(n))        // Tile
+		p.WriteOAM(n*4+2, byte(n)&0x23)   // Attributes
+		p.WriteOAM(n*4+3, byte(n%8*32+4)) // X
+	}
+	p.WritePPUMASK(0x1E)
+	return p
+}
+
+// Each op is one NTSC frame.
+func BenchmarkPPUStep(b *testing.B) {
+	p := newBenchPPU()
+	cycles := int(p.timing.CyclesPerFrame())
+	b.ReportAllocs()
+	for b.Loop() {
+		p.Step(cycles)
+	}
+}
@@ -0,0 +1,367 @@
+package ppu
+
+import (
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/ppu/bus"
+	"testing"
+)
+
+// newTestPPU returns a PPU on an NROM cartridge whose tile 1 is opaque
+// (pattern value 1) and the other tiles are transparent.
+// All sprites are off screen (Y = $FF) and the nametables are tile 0.
+func newTestPPU() *PPU {
+	rom := make([]byte, 0x10+0x4000+0x2000)
+	copy(rom, "NES\x1a\x01\x01")
+	chr := rom[0x10+0x4000:]
+	for i := 0x10; i < 0x18; i++ {
+		chr[i] = 0xFF
+	}
+	p := NewPPU(bus.NewBus(cartridge.NewCartridge(rom)))
+	for addr := uint16(0); addr < 0x100; addr++ {
+		p.WriteOAM(addr, 0xFF)
+	}
+	return p
+}
+
+// fillNametables sets every nametable byte (and attribute byte) to tile.
+func fillNametables(p *PPU, tile byte) {
+	for addr := uint16(0x2000); addr < 0x3000; addr++ {
+		p.Bus.Write(addr, tile)
+	}
+}
+
+// runTo ticks until the next dot is dot of line.
+func runTo(p *PPU, line, dot int) {
+	for p.ly != line || p.dot != dot {
+		p.tick()
+	}
+}
+
+func TestSpriteEvaluation(t *testing.T) {
+	tests := []struct {
+		name         string
+		oam          map[uint16]byte // Bytes set after the 8 sprites on line 20
+		isUnlimited  bool
+		wantCount    int
+		wantOverflow bool
+	}{
+		{"8 sprites", nil, false, 8, false},
+		{"9 sprites", map[uint16]byte{8*4 + 0: 20}, false, 8, true},
+		{"9 sprites without limit", map[uint16]byte{8*4 + 0: 20}, true, 9, true},
+		// After 8 sprites, sprite 9 is read at m = 1 (its tile byte) as Y.
+		{"false positive", map[uint16]byte{9*4 + 1: 20}, false, 8, true},
+		{"false negative", map[uint16]byte{9*4 + 0: 20}, false, 8, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPPU()
+			for n := uint16(0); n < 8; n++ {
+				p.WriteOAM(n*4+0, 20)
+				p.WriteOAM(n*4+1, 1)
+				p.WriteOAM(n*4+3, byte(n*16))
+			}
+			for addr, val := range tt.oam {
+				p.WriteOAM(addr, val)
+			}
+			p.SetIsSpriteLimitDisabled(tt.isUnlimited)
+			p.WritePPUMASK(0x1E)
+
+			runTo(p, 21, 0)
+			if p.lineSpriteCount != tt.wantCount {
+				t.Errorf("sprites on line 21 = %d, want %d", p.lineSpriteCount, tt.wantCount)
+			}
+			if got := p.ppustatus&SpriteOverflowFlag != 0; got != tt.wantOverflow {
+				t.Errorf("overflow flag = %v, want %v", got, tt.wantOverflow)
+			}
+		})
+	}
+}
+
+func TestSpriteOverflowClearedOnPreRenderLine(t *testing.T) {
+	p := newTestPPU()
+	for n := uint16(0); n < 9; n++ {
+		p.WriteOAM(n*4+0, 20)
+	}
+	p.WritePPUMASK(0x18)
+	runTo(p, 21, 0)
+	if p.ppustatus&SpriteOverflowFlag == 0 {
+		t.Fatal("overflow flag is not set")
+	}
+	runTo(p, p.timing.LinesPerFrame-1, 2)
+	if p.ppustatus&SpriteOverflowFlag != 0 {
+		t.Error("overflow flag is not cleared on the pre-render line")
+	}
+}
+
+func TestSprite0Hit(t *testing.T) {
+	tests := []struct {
+		name    string
+		x       byte
+		ppumask byte
+		want    bool
+	}{
+		{"middle", 100, 0x1E, true},
+		{"x = 255", 255, 0x1E, false},
+		{"left 8 shown", 0, 0x1E, true},
+		{"left 8 clipped", 0, 0x18, false},
+		{"left 8 BG clipped", 0, 0x1C, false},
+		{"left 8 sprites clipped", 0, 0x1A, false},
+		{"left 8 clipped, x = 4", 4, 0x18, true}, // Pixels 8 ~ 11 overlap.
+		{"BG off", 100, 0x14, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPPU()
+			fillNametables(p, 1)
+			p.WriteOAM(0, 50)
+			p.WriteOAM(1, 1)
+			p.WriteOAM(3, tt.x)
+			p.WritePPUMASK(tt.ppumask)
+
+			runTo(p, 240, 0)
+			if got := p.ppustatus&Sprite0HitFlag != 0; got != tt.want {
+				t.Errorf("sprite 0 hit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSprite0HitTiming(t *testing.T) {
+	p := newTestPPU()
+	fillNametables(p, 1)
+	p.WriteOAM(0, 50) // Drawn from line 51
+	p.WriteOAM(1, 1)
+	p.WriteOAM(3, 100)
+	p.WritePPUMASK(0x1E)
+
+	runTo(p, 51, 101) // Dot 101 outputs pixel 100.
+	if p.ppustatus&Sprite0HitFlag != 0 {
+		t.Fatal("sprite 0 hit is set before the overlapping pixel")
+	}
+	p.tick()
+	if p.ppustatus&Sprite0HitFlag == 0 {
+		t.Fatal("sprite 0 hit is not set on the overlapping pixel")
+	}
+	runTo(p, p.timing.LinesPerFrame-1, 2)
+	if p.ppustatus&Sprite0HitFlag != 0 {
+		t.Error("sprite 0 hit is not cleared on the pre-render line")
+	}
+}
+
+func TestComposite(t *testing.T) {
+	tests := []struct {
+		name           string
+		bgPixel, bgPal byte
+		spPixel, spPal byte
+		isBehindBG     bool
+		want           uint16
+	}{
+		{"both transparent", 0, 1, 0, 4, false, 0x3F00},
+		{"BG only", 2, 1, 0, 4, false, 0x3F06},
+		{"sprite only", 0, 1, 3, 4, false, 0x3F13},
+		{"behind sprite only", 0, 1, 3, 4, true, 0x3F13},
+		{"sprite in front", 2, 1, 3, 4, false, 0x3F13},
+		{"sprite behind", 2, 1, 3, 4, true, 0x3F06},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := composite(tt.bgPixel, tt.bgPal, tt.spPixel, tt.spPal, tt.isBehindBG)
+			if got != tt.want {
+				t.Errorf("composite() = $%04X, want $%04X", got, tt.want)
+			}
+		})
+	}
+}
+
+// A sprite behind the BG still wins over later sprites,
+// so it hides them under opaque BG pixels.
+func TestBehindSpriteHidesLaterSprites(t *testing.T) {
+	p := newTestPPU()
+	p.WritePPUMASK(0x1E)
+	p.lineSprites[0] = sprite{X: 10, Attr: 0x21, Lo: 0xFF}
+	p.lineSprites[1] = sprite{X: 10, Attr: 0x02, Lo: 0xFF, Hi: 0xFF}
+	p.lineSpriteCount = 2
+
+	for _, bgPixel := range []byte{0, 1} {
+		spPixel, spPal, isBehindBG := p.spritePixelAt(12, bgPixel)
+		if spPixel != 1 || spPal != 5 || !isBehindBG {
+			t.Fatalf("spritePixelAt() = %d, %d, %v, want 1, 5, true", spPixel, spPal, isBehindBG)
+		}
+		got := composite(bgPixel, 0, spPixel, spPal, isBehindBG)
+		want := uint16(0x3F15)
+		if bgPixel != 0 {
+			want = 0x3F01
+		}
+		if got != want {
+			t.Errorf("BG pixel %d: composite() = $%04X, want $%04X", bgPixel, got, want)
+		}
+	}
+}
+
+func TestReadPPUDATABuffer(t *testing.T) {
+	p := newTestPPU()
+	p.WritePPUADDR(0x20)
+	p.WritePPUADDR(0x00)
+	p.WritePPUDATA(0x11)
+	p.WritePPUDATA(0x22)
+
+	p.WritePPUADDR(0x20)
+	p.WritePPUADDR(0x00)
+	for i, want := range []byte{0x00, 0x11, 0x22} {
+		if got := p.ReadPPUDATA(); got != want {
+			t.Errorf("read %d = $%02X, want $%02X", i, got, want)
+		}
+	}
+}
+
+func TestReadPPUDATAIncrement32(t *testing.T) {
+	p := newTestPPU()
+	p.Bus.Write(0x2020, 0x33)
+	p.WritePPUCTRL(0x04)
+	p.WritePPUADDR(0x20)
+	p.WritePPUADDR(0x00)
+	p.ReadPPUDATA()
+	p.ReadPPUDATA()
+	if got := p.ReadPPUDATA(); got != 0x33 {
+		t.Errorf("read = $%02X, want $33", got)
+	}
+	if p.v != 0x2060 {
+		t.Errorf("v = $%04X, want $2060", p.v)
+	}
+}
+
+// Palette reads are not buffered. Bits 6 ~ 7 are open bus, and the buffer
+// gets the nametable byte at the address - $1000.
+func TestReadPPUDATAPalette(t *testing.T) {
+	p := newTestPPU()
+	p.Bus.Write(0x3F05, 0x2A)
+	p.Bus.Write(0x2FC5, 0x77)
+	p.WritePPUADDR(0x3F)
+	p.WritePPUADDR(0xC5) // $3FC5 mirrors $3F05. The I/O latch is $C5.
+
+	if got := p.ReadPPUDATA(); got != 0xEA {
+		t.Errorf("palette read = $%02X, want $EA", got)
+	}
+	p.WritePPUADDR(0x20)
+	p.WritePPUADDR(0x00)
+	if got := p.ReadPPUDATA(); got != 0x77 {
+		t.Errorf("buffer after palette read = $%02X, want $77", got)
+	}
+}
+
+func TestIOLatchDecay(t *testing.T) {
+	p := newTestPPU()
+	p.Bus.Write(0x3F05, 0x2A)
+	p.WritePPUADDR(0x3F)
+	p.WritePPUADDR(0x05)
+	p.WriteOAMADDR(0xFF)
+
+	decay := func(frames int) {
+		for i := 0; i < frames; i++ {
+			p.decayIOLatch()
+		}
+	}
+	decay(20)
+	if got := p.ReadIOLatch(); got != 0xFF {
+		t.Fatalf("latch after 20 frames = $%02X, want $FF", got)
+	}
+	// The palette read drives bits 0 ~ 5 only.
+	if got := p.ReadPPUDATA(); got != 0xEA {
+		t.Fatalf("palette read = $%02X, want $EA", got)
+	}
+
+	steps := []struct {
+		frames int
+		want   byte
+	}{
+		{15, 0xEA}, // 35 frames since bits 6 ~ 7 were driven
+		{1, 0x2A},
+		{19, 0x2A}, // 35 frames since bits 0 ~ 5 were driven
+		{1, 0x00},
+	}
+	for _, s := range steps {
+		decay(s.frames)
+		if got := p.ReadIOLatch(); got != s.want {
+			t.Fatalf("latch after %d more frames = $%02X, want $%02X", s.frames, got, s.want)
+		}
+	}
+}
+
+func TestIOLatchDecaysOncePerFrame(t *testing.T) {
+	p := newTestPPU()
+	p.WriteOAMADDR(0xFF)
+	for frame := 0; frame < ioLatchDecayFrames-1; frame++ {
+		runTo(p, p.timing.VBlankLine, 2)
+		runTo(p, 0, 0)
+	}
+	if got := p.ReadIOLatch(); got != 0xFF {
+		t.Fatalf("latch after %d frames = $%02X, want $FF", ioLatchDecayFrames-1, got)
+	}
+	runTo(p, p.timing.VBlankLine, 2)
+	if got := p.ReadIOLatch(); got != 0x00 {
+		t.Errorf("latch after %d frames = $%02X, want $00", ioLatchDecayFrames, got)
+	}
+}
+
+func TestReadPPUSTATUSVBlankRace(t *testing.T) {
+	tests := []struct {
+		dot      int // Next dot when PPUSTATUS is read
+		wantFlag bool
+		wantNMI  bool
+	}{
+		{0, false, true}, // Too early to race: VBlank starts normally.
+		{1, false, false},
+		{2, true, false},
+		{3, true, false},
+		{4, true, true},
+	}
+	for _, tt := range tests {
+		p := newTestPPU()
+		p.WritePPUCTRL(VblankNMIEnable)
+		runTo(p, p.timing.VBlankLine, tt.dot)
+
+		val := p.ReadPPUSTATUS()
+		if got := val&VblankFlag != 0; got != tt.wantFlag {
+			t.Errorf("dot %d: VBlank flag read = %v, want %v", tt.dot, got, tt.wantFlag)
+		}
+		runTo(p, p.timing.VBlankLine, 10)
+		if got := p.HasNMI(); got != tt.wantNMI {
+			t.Errorf("dot %d: NMI = %v, want %v", tt.dot, got, tt.wantNMI)
+		}
+		if tt.dot == 0 && p.ppustatus&VblankFlag == 0 {
+			t.Errorf("dot %d: VBlank flag is not set", tt.dot)
+		}
+	}
+}
+
+// Each 0 -> 1 of the NMI enable bit while the VBlank flag is set raises an NMI.
+func TestPPUCTRLNMIEdge(t *testing.T) {
+	p := newTestPPU()
+	runTo(p, p.timing.VBlankLine, 10)
+	if p.HasNMI() {
+		t.Fatal("NMI while NMI is disabled")
+	}
+
+	p.WritePPUCTRL(VblankNMIEnable)
+	if !p.HasNMI() {
+		t.Fatal("no NMI after enabling NMI in VBlank")
+	}
+	p.DisableNMI()
+	p.WritePPUCTRL(VblankNMIEnable)
+	if p.HasNMI() {
+		t.Error("NMI without a rising edge")
+	}
+	p.WritePPUCTRL(0)
+	p.WritePPUCTRL(VblankNMIEnable)
+	if !p.HasNMI() {
+		t.Error("no NMI after toggling NMI enable")
+	}
+
+	p.DisableNMI()
+	p.ReadPPUSTATUS()
+	p.WritePPUCTRL(0)
+	p.WritePPUCTRL(VblankNMIEnable)
+	if p.HasNMI() {
+		t.Error("NMI after the VBlank flag was cleared")
+	}
+}
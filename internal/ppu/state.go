@@ -0,0 +1,183 @@
+package ppu
+
+import "fmt"
+
+// State is a serializable snapshot of the PPU, used for save states.
+type State struct {
+	// Both frames: the last one is shown until the one being drawn is done,
+	// and the Zapper reads either.
+	Viewport [2][256 * 240]uint16
+	Front    int
+
+	OAM                [256]byte
+	Dot                int
+	LY                 int
+	IsOddFrame         bool
+	DotPhase           int
+	V                  uint16
+	T                  uint16
+	X                  uint8
+	W                  bool
+	PPUCTRL            byte
+	PPUMASK            byte
+	PPUSTATUS          byte
+	OAMADDR            byte
+	NMIOutput          bool
+	HasNMI             bool
+	IsVBlankSuppressed bool
+
+	ReadBuffer   byte
+	IOLatch      byte
+	IOLatchDecay [8]int
+
+	NTByte    byte
+	ATByte    byte
+	BGLo      byte
+	BGHi      byte
+	BGShiftLo uint16
+	BGShiftHi uint16
+	ATShiftLo uint16
+	ATShiftHi uint16
+
+	EvalSprites     [64]sprite
+	EvalSpriteCount int
+	LineSprites     [64]sprite
+	LineSpriteCount int
+	IsSprite0InEval bool
+	IsSprite0OnLine bool
+
+	EvalN    int
+	EvalM    int
+	EvalWait int
+	EvalDone bool
+
+	IsBackdropFilled bool
+}
+
+func (p *PPU) SaveState() State {
+	return State{
+		Viewport: p.viewport,
+		Front:    p.front,
+
+		OAM:                p.oam,
+		Dot:                p.dot,
+		LY:                 p.ly,
+		IsOddFrame:         p.isOddFrame,
+		DotPhase:           p.dotPhase,
+		V:                  p.v,
+		T:                  p.t,
+		X:                  p.x,
+		W:                  p.w,
+		PPUCTRL:            p.ppuctrl,
+		PPUMASK:            p.ppumask,
+		PPUSTATUS:          p.ppustatus,
+		OAMADDR:            p.oamaddr,
+		NMIOutput:          p.nmiOutput,
+		HasNMI:             p.hasNMI,
+		IsVBlankSuppressed: p.isVBlankSuppressed,
+
+		ReadBuffer:   p.readBuffer,
+		IOLatch:      p.ioLatch,
+		IOLatchDecay: p.ioLatchDecay,
+
+		NTByte:    p.ntByte,
+		ATByte:    p.atByte,
+		BGLo:      p.bgLo,
+		BGHi:      p.bgHi,
+		BGShiftLo: p.bgShiftLo,
+		BGShiftHi: p.bgShiftHi,
+		ATShiftLo: p.atShiftLo,
+		ATShiftHi: p.atShiftHi,
+
+		EvalSprites:     p.evalSprites,
+		EvalSpriteCount: p.evalSpriteCount,
+		LineSprites:     p.lineSprites,
+		LineSpriteCount: p.lineSpriteCount,
+		IsSprite0InEval: p.isSprite0InEval,
+		IsSprite0OnLine: p.isSprite0OnLine,
+
+		EvalN:    p.evalN,
+		EvalM:    p.evalM,
+		EvalWait: p.evalWait,
+		EvalDone: p.evalDone,
+
+		IsBackdropFilled: p.isBackdropFilled,
+	}
+}
+
+// checkState fails if s holds a position or a sprite index the PPU can't have,
+// so that a corrupted save state can't make it index out of range.
+func (p *PPU) checkState(s State) error {
+	switch {
+	case s.Front != 0 && s.Front != 1:
+		return fmt.Errorf("ppu: save state has front viewport %d", s.Front)
+	case s.LY < 0 || s.LY >= p.timing.LinesPerFrame:
+		return fmt.Errorf("ppu: save state has line %d", s.LY)
+	case s.Dot < 0 || s.Dot > 340:
+		return fmt.Errorf("ppu: save state has dot %d", s.Dot)
+	case s.DotPhase < 0 || s.DotPhase >= 5:
+		return fmt.Errorf("ppu: save state has dot phase %d", s.DotPhase)
+	case s.EvalSpriteCount < 0 || s.EvalSpriteCount > len(s.EvalSprites):
+		return fmt.Errorf("ppu: save state has %d evaluated sprites", s.EvalSpriteCount)
+	case s.LineSpriteCount < 0 || s.LineSpriteCount > len(s.LineSprites):
+		return fmt.Errorf("ppu: save state has %d sprites on the line", s.LineSpriteCount)
+	case s.EvalN < 0 || s.EvalN > 64 || s.EvalM < 0 || s.EvalM > 3:
+		return fmt.Errorf("ppu: save state has sprite evaluation at n=%d m=%d", s.EvalN, s.EvalM)
+	case s.EvalWait < 0 || s.EvalWait > 6:
+		return fmt.Errorf("ppu: save state has sprite copy wait %d", s.EvalWait)
+	}
+	return nil
+}
+
+func (p *PPU) LoadState(s State) error {
+	if err := p.checkState(s); err != nil {
+		return err
+	}
+	p.viewport = s.Viewport
+	p.front = s.Front
+
+	p.oam = s.OAM
+	p.dot = s.Dot
+	p.ly = s.LY
+	p.isOddFrame = s.IsOddFrame
+	p.dotPhase = s.DotPhase
+	p.v = s.V
+	p.t = s.T
+	p.x = s.X
+	p.w = s.W
+	p.ppuctrl = s.PPUCTRL
+	p.ppumask = s.PPUMASK
+	p.ppustatus = s.PPUSTATUS
+	p.oamaddr = s.OAMADDR
+	p.nmiOutput = s.NMIOutput
+	p.hasNMI = s.HasNMI
+	p.isVBlankSuppressed = s.IsVBlankSuppressed
+
+	p.readBuffer = s.ReadBuffer
+	p.ioLatch = s.IOLatch
+	p.ioLatchDecay = s.IOLatchDecay
+
+	p.ntByte = s.NTByte
+	p.atByte = s.ATByte
+	p.bgLo = s.BGLo
+	p.bgHi = s.BGHi
+	p.bgShiftLo = s.BGShiftLo
+	p.bgShiftHi = s.BGShiftHi
+	p.atShiftLo = s.ATShiftLo
+	p.atShiftHi = s.ATShiftHi
+
+	p.evalSprites = s.EvalSprites
+	p.evalSpriteCount = s.EvalSpriteCount
+	p.lineSprites = s.LineSprites
+	p.lineSpriteCount = s.LineSpriteCount
+	p.isSprite0InEval = s.IsSprite0InEval
+	p.isSprite0OnLine = s.IsSprite0OnLine
+
+	p.evalN = s.EvalN
+	p.evalM = s.EvalM
+	p.evalWait = s.EvalWait
+	p.evalDone = s.EvalDone
+
+	p.isBackdropFilled = s.IsBackdropFilled
+	return nil
+}
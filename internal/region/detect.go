@@ -0,0 +1,78 @@
+package region
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DB maps the CRC32 of a ROM without its 16-byte header to its region.
+// It corrects ROMs whose header does not tell the region (iNES 1.0) or tells it wrong.
+type DB map[uint32]Region
+
+// ParseDB reads a ROM database.
+// One ROM per line: "<crc32 in hex> <region> [name]", e.g. "1A2B3C4D pal Some Game (Europe)".
+// Empty lines and lines starting with '#' are ignored.
+func ParseDB(r io.Reader) (DB, error) {
+	db := DB{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: want \"<crc32> <region> [name]\"", n)
+		}
+		crc, err := strconv.ParseUint(fields[0], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad CRC32 %q", n, fields[0])
+		}
+		region, err := Parse(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		db[uint32(crc)] = region
+	}
+	return db, sc.Err()
+}
+
+// LoadDBFile reads a ROM database file. See ParseDB().
+func LoadDBFile(path string) (DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDB(f)
+}
+
+// Detect returns the region of an iNES ROM: from the database if it knows
+// the ROM, else from the NES 2.0 header, else NTSC.
+// The source tells which one decided ("db", "header" or "default").
+func Detect(rom []byte, db DB) (region Region, source string) {
+	if len(rom) < 0x10 {
+		return NTSC, "default"
+	}
+	if r, ok := db[crc32.ChecksumIEEE(rom[0x10:])]; ok {
+		return r, "db"
+	}
+	// NES 2.0: byte 7 bits 2 ~ 3 = 2, byte 12 bits 0 ~ 1 = CPU/PPU timing.
+	// iNES 1.0 byte 9 also has a PAL bit, but it is rarely set correctly, so it is ignored.
+	if rom[7]&0x0C == 0x08 {
+		switch rom[12] & 0x03 {
+		case 0, 2: // NTSC, multiple-region
+			return NTSC, "header"
+		case 1:
+			return PAL, "header"
+		case 3:
+			return Dendy, "header"
+		}
+	}
+	return NTSC, "default"
+}
@@ -0,0 +1,121 @@
+package region
+
+import (
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newROM returns an iNES ROM with 16 KB of PRG-ROM filled with fill.
+// If isNES20 is set, its header is NES 2.0 with the given timing.
+func newROM(fill byte, isNES20 bool, timing byte) []byte {
+	rom := make([]byte, 0x10+0x4000)
+	copy(rom, "NES\x1A")
+	rom[4] = 1
+	if isNES20 {
+		rom[7] = 0x08
+		rom[12] = timing
+	}
+	for i := 0x10; i < len(rom); i++ {
+		rom[i] = fill
+	}
+	return rom
+}
+
+func TestParseDB(t *testing.T) {
+	db, err := ParseDB(strings.NewReader(`# Comment
+1a2b3c4d pal Some Game (Europe)
+
+  DEADBEEF dendy
+00000001 NTSC
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DB{0x1A2B3C4D: PAL, 0xDEADBEEF: Dendy, 0x00000001: NTSC}
+	if len(db) != len(want) {
+		t.Fatalf("ParseDB() = %v, want %v", db, want)
+	}
+	for crc, r := range want {
+		if db[crc] != r {
+			t.Errorf("db[%08X] = %v, want %v", crc, db[crc], r)
+		}
+	}
+}
+
+func TestParseDBErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"missing region", "1A2B3C4D\n"},
+		{"bad CRC32", "XYZ pal\n"},
+		{"CRC32 too long", "1A2B3C4D5 pal\n"},
+		{"unknown region", "1A2B3C4D secam\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDB(strings.NewReader("# ok\n" + tt.in)); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+				t.Errorf("ParseDB() error = %v, want a line 2 error", err)
+			}
+		})
+	}
+}
+
+func TestLoadDBFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "romdb.txt")
+	if err := os.WriteFile(path, []byte("1A2B3C4D pal\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadDBFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db[0x1A2B3C4D] != PAL {
+		t.Errorf("db = %v, want the ROM as pal", db)
+	}
+	if _, err := LoadDBFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadDBFile() of a missing file succeeded")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	known := newROM(0xEA, false, 0)
+	db := DB{crc32.ChecksumIEEE(known[0x10:]): Dendy}
+	tests := []struct {
+		name       string
+		rom        []byte
+		db         DB
+		want       Region
+		wantSource string
+	}{
+		{"iNES 1.0", newROM(0, false, 0), nil, NTSC, "default"},
+		{"iNES 1.0 PAL bit ignored", func() []byte { rom := newROM(0, false, 0); rom[9] = 1; return rom }(), nil, NTSC, "default"},
+		{"NES 2.0 NTSC", newROM(0, true, 0), nil, NTSC, "header"},
+		{"NES 2.0 PAL", newROM(0, true, 1), nil, PAL, "header"},
+		{"NES 2.0 multiple-region", newROM(0, true, 2), nil, NTSC, "header"},
+		{"NES 2.0 Dendy", newROM(0, true, 3), nil, Dendy, "header"},
+		{"db", known, db, Dendy, "db"},
+		// The database corrects a wrong header.
+		{"db over header", newROM(0xEA, true, 1), db, Dendy, "db"},
+		{"db miss", newROM(0, true, 1), db, PAL, "header"},
+		{"short", []byte("NES\x1A"), db, NTSC, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, source := Detect(tt.rom, tt.db)
+			if r != tt.want || source != tt.wantSource {
+				t.Errorf("Detect() = %v, %q, want %v, %q", r, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+// The bundled database must parse.
+func TestBundledDB(t *testing.T) {
+	if _, err := LoadDBFile("../../romdb.txt"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,115 @@
+package region
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region is the TV system a console was built for.
+type Region int
+
+const (
+	NTSC  Region = iota // North America, Japan
+	PAL                 // Europe, Australia
+	Dendy               // Famiclone sold in Russia: PAL frame timing with an NTSC-speed CPU
+)
+
+func (r Region) String() string {
+	switch r {
+	case PAL:
+		return "pal"
+	case Dendy:
+		return "dendy"
+	default:
+		return "ntsc"
+	}
+}
+
+// Parse reads a region name: "ntsc", "pal" or "dendy".
+func Parse(s string) (Region, error) {
+	switch strings.ToLower(s) {
+	case "ntsc":
+		return NTSC, nil
+	case "pal":
+		return PAL, nil
+	case "dendy":
+		return Dendy, nil
+	default:
+		return NTSC, fmt.Errorf("unknown region %q", s)
+	}
+}
+
+// Timing is the clock, video and audio timing of a region.
+type Timing struct {
+	CPUClock           float64 // Hz
+	FrameRate          int     // Frames emulated per second of host time
+	LinesPerFrame      int     // Including VBlank and the pre-render line
+	VBlankLine         int     // Line on which VBlank starts
+	PPUDotsPer5Cycles  int     // PPU dots per 5 CPU cycles (3 or 3.2 per cycle)
+	IsOddFrameSkipping bool    // The last dot of the pre-render line is skipped on odd frames.
+	IsEmphasisSwapped  bool    // PPUMASK bits 5 and 6 emphasize green and red.
+	FrameCounterPeriod int     // CPU cycles between APU frame IRQs (4-step mode)
+	FrameCounterSteps  [5]int  // CPU cycles of the APU frame counter steps (the 5th is in 5-step mode only)
+	NoisePeriods       [16]int // CPU cycles per noise shift, by $400E bits 0 ~ 3
+	DMCPeriods         [16]int // CPU cycles per DMC output bit, by $4010 bits 0 ~ 3
+}
+
+// The noise and DMC periods are in CPU cycles, so PAL has its own tables to
+// keep the pitches close to NTSC.
+var (
+	ntscNoisePeriods = [16]int{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068}
+	palNoisePeriods  = [16]int{4, 8, 14, 30, 60, 88, 118, 148, 188, 236, 354, 472, 708, 944, 1890, 3778}
+	ntscDMCPeriods   = [16]int{428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54}
+	palDMCPeriods    = [16]int{398, 354, 316, 298, 276, 236, 210, 198, 176, 148, 132, 118, 98, 78, 66, 50}
+
+	ntscFrameCounterSteps = [5]int{7457, 14913, 22371, 29829, 37281}
+	palFrameCounterSteps  = [5]int{8313, 16627, 24939, 33253, 41565}
+)
+
+var timings = [...]Timing{
+	NTSC: {
+		CPUClock:           1789773,
+		FrameRate:          60,
+		LinesPerFrame:      262,
+		VBlankLine:         241,
+		PPUDotsPer5Cycles:  15,
+		IsOddFrameSkipping: true,
+		FrameCounterPeriod: 29830,
+		FrameCounterSteps:  ntscFrameCounterSteps,
+		NoisePeriods:       ntscNoisePeriods,
+		DMCPeriods:         ntscDMCPeriods,
+	},
+	PAL: {
+		CPUClock:           1662607,
+		FrameRate:          50,
+		LinesPerFrame:      312,
+		VBlankLine:         241,
+		PPUDotsPer5Cycles:  16,
+		IsEmphasisSwapped:  true,
+		FrameCounterPeriod: 33254,
+		FrameCounterSteps:  palFrameCounterSteps,
+		NoisePeriods:       palNoisePeriods,
+		DMCPeriods:         palDMCPeriods,
+	},
+	Dendy: {
+		CPUClock:           1773448,
+		FrameRate:          50,
+		LinesPerFrame:      312,
+		VBlankLine:         291, // 51 post-render lines keep the NTSC-length VBlank.
+		PPUDotsPer5Cycles:  15,
+		IsEmphasisSwapped:  true,
+		FrameCounterPeriod: 29830, // The APU runs as on NTSC.
+		FrameCounterSteps:  ntscFrameCounterSteps,
+		NoisePeriods:       ntscNoisePeriods,
+		DMCPeriods:         ntscDMCPeriods,
+	},
+}
+
+func (r Region) Timing() Timing {
+	return timings[r]
+}
+
+// CyclesPerFrame returns the CPU cycles run per emulated frame.
+func (t Timing) CyclesPerFrame() float64 {
+	return t.CPUClock / float64(t.FrameRate)
+}
@@ -0,0 +1,149 @@
+// Package nes is the public API of the NESutaro emulator core.
+// It lets other Go programs run a ROM frame by frame without any front-end:
+//
+//	console, err := nes.New(rom)
+//	...
+//	console.SetController(0, nes.ButtonStart)
+//	if err := console.StepFrame(); err != nil { ... }
+//	img := console.Frame()
+package nes
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"nesutaro/internal/apu"
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/emulator"
+	"nesutaro/internal/ppu/palette"
+)
+
+const (
+	ScreenWidth  = 256
+	ScreenHeight = 240
+	SampleRate   = apu.SampleRate // Hz, of AudioSamples
+)
+
+// Button is a set of controller buttons, in the order the NES reads them.
+type Button byte
+
+const (
+	ButtonA Button = 1 << iota
+	ButtonB
+	ButtonSelect
+	ButtonStart
+	ButtonUp
+	ButtonDown
+	ButtonLeft
+	ButtonRight
+)
+
+// ErrCPUHalted is returned by StepFrame once the CPU has hit an
+// unrecoverable state (e.g. a JAM opcode). The console cannot run further.
+var ErrCPUHalted = errors.New("nes: CPU halted")
+
+// Console is an emulated NES with a ROM inserted.
+// It is not safe for concurrent use.
+type Console struct {
+	emu *emulator.Emulator
+}
+
+// New creates a console from an iNES ROM image.
+// An error is returned if the image is malformed or its mapper is not supported.
+func New(rom []byte) (*Console, error) {
+	if err := cartridge.CheckROM(rom); err != nil {
+		return nil, fmt.Errorf("nes: %w", err)
+	}
+	return &Console{emu: emulator.NewEmulator(rom)}, nil
+}
+
+// NewFromReader reads an iNES ROM image from r and creates a console from it.
+func NewFromReader(r io.Reader) (*Console, error) {
+	rom, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return New(rom)
+}
+
+// StepFrame runs the console for the duration of one video frame.
+func (c *Console) StepFrame() error {
+	if c.emu.RunFrame() == -1 {
+		return ErrCPUHalted
+	}
+	return nil
+}
+
+// SetController sets the buttons held on a controller port (0 or 1).
+// The state is kept until it is set again and is latched by the game
+// when it strobes the controller.
+func (c *Console) SetController(port int, buttons Button) error {
+	if port != 0 && port != 1 {
+		return fmt.Errorf("nes: controller port %d is not available", port)
+	}
+	c.emu.Joypad(port).SetInputs(byte(buttons))
+	return nil
+}
+
+// Frame returns a copy of the last completed frame as RGBA.
+func (c *Console) Frame() *image.RGBA {
+	src := c.emu.GetGameScreen()
+	dst := image.NewRGBA(src.Rect)
+	copy(dst.Pix, src.Pix)
+	return dst
+}
+
+// FrameIndexed returns a copy of the last completed frame as 9-bit palette
+// indices, ScreenWidth*ScreenHeight entries, row by row.
+// Bits 0 ~ 5 are the NES color (after greyscale), bits 6 ~ 8 are the
+// PPUMASK emphasis bits. They index a 512-entry .pal file.
+func (c *Console) FrameIndexed() []uint16 {
+	viewport := c.emu.CPU.Bus.PPU.GetViewport()
+	return append([]uint16(nil), viewport[:]...)
+}
+
+// SetPalette replaces the palette used by Frame with the contents of a .pal
+// file (64 or 512 entries). The built-in palette is used until then.
+func (c *Console) SetPalette(pal []byte) error {
+	p, err := palette.Load(pal)
+	if err != nil {
+		return fmt.Errorf("nes: %w", err)
+	}
+	c.emu.Palette = p
+	return nil
+}
+
+// AudioSamples returns the audio samples produced since the last call:
+// mono, SampleRate Hz, about -1 ~ 1 (about 735 per NTSC frame).
+// Samples not taken within a second are dropped.
+func (c *Console) AudioSamples() []float32 {
+	return c.emu.CPU.Bus.APU.TakeSamples(nil)
+}
+
+// ReadMemory reads a byte from the CPU address space.
+// The read has the same side effects as a CPU read
+// (e.g. reading $2002 clears the PPU write toggle).
+func (c *Console) ReadMemory(addr uint16) byte {
+	return c.emu.CPU.Bus.Read(addr)
+}
+
+// WriteMemory writes a byte to the CPU address space,
+// with the same side effects as a CPU write.
+func (c *Console) WriteMemory(addr uint16, val byte) {
+	c.emu.CPU.Bus.Write(addr, val)
+}
+
+// SaveState returns a snapshot of the whole console.
+func (c *Console) SaveState() ([]byte, error) {
+	return c.emu.SaveState()
+}
+
+// LoadState restores a snapshot made by SaveState on a console
+// running the same ROM. The current state is kept on error.
+func (c *Console) LoadState(data []byte) error {
+	if err := c.emu.LoadState(data); err != nil {
+		return fmt.Errorf("nes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package nes
+
+import (
+	"bytes"
+	"encoding/gob"
+	"nesutaro/internal/apu"
+	"nesutaro/internal/cartridge"
+	"nesutaro/internal/cpu"
+	cbus "nesutaro/internal/cpu/bus"
+	"nesutaro/internal/joypad"
+	"nesutaro/internal/ppu"
+	pbus "nesutaro/internal/ppu/bus"
+	"nesutaro/internal/region"
+	"slices"
+	"testing"
+)
+
+// testROM builds an NROM image whose NMI handler counts frames in $11
+// and writes the count to the backdrop color, so every frame differs.
+func testROM() []byte {
+	prg := make([]byte, 0x4000)
+	copy(prg, []byte{
+		0x78,       // SEI
+		0xA2, 0xFF, // LDX #$FF
+		0x9A,       // TXS
+		0xA9, 0x80, // LDA #$80
+		0x8D, 0x00, 0x20, // STA $2000 (NMI on)
+		0x4C, 0x09, 0x80, // JMP $8009
+	})
+	copy(prg[0x10:], []byte{
+		0xE6, 0x11, // INC $11
+		0xA9, 0x3F, // LDA #$3F
+		0x8D, 0x06, 0x20, // STA $2006
+		0xA9, 0x00, // LDA #$00
+		0x8D, 0x06, 0x20, // STA $2006
+		0xA5, 0x11, // LDA $11
+		0x29, 0x3F, // AND #$3F
+		0x8D, 0x07, 0x20, // STA $2007
+		0x40, // RTI
+	})
+	copy(prg[0x3FFA:], []byte{0x10, 0x80, 0x00, 0x80, 0x00, 0x80}) // NMI, RESET, IRQ
+	header := []byte{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rom := append(header, prg...)
+	return append(rom, make([]byte, 0x2000)...)
+}
+
+func newTestConsole(t *testing.T) *Console {
+	t.Helper()
+	c, err := New(testROM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func stepFrames(t *testing.T, c *Console, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := c.StepFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewRejectsBadROMs(t *testing.T) {
+	rom := testROM()
+	mapper1 := slices.Clone(rom)
+	mapper1[6] = 0x10
+	noPRG := slices.Clone(rom)
+	noPRG[4] = 0
+	for name, bad := range map[string][]byte{
+		"empty":       nil,
+		"bad magic":   append([]byte("NES\x00"), rom[4:]...),
+		"truncated":   rom[:0x1000],
+		"no PRG ROM":  noPRG,
+		"mapper 1":    mapper1,
+		"header only": rom[:0x10],
+	} {
+		if _, err := New(bad); err == nil {
+			t.Errorf("New(%s): no error", name)
+		}
+		if _, err := NewFromReader(bytes.NewReader(bad)); err == nil {
+			t.Errorf("NewFromReader(%s): no error", name)
+		}
+	}
+	if _, err := NewFromReader(bytes.NewReader(rom)); err != nil {
+		t.Errorf("NewFromReader: %v", err)
+	}
+}
+
+func TestStepFrame(t *testing.T) {
+	c := newTestConsole(t)
+	stepFrames(t, c, 10)
+	// The first NMI comes at the end of the first frame.
+	if n := c.ReadMemory(0x11); n < 9 || n > 10 {
+		t.Errorf("NMI count after 10 frames = %d", n)
+	}
+	if img := c.Frame(); img.Rect.Dx() != ScreenWidth || img.Rect.Dy() != ScreenHeight {
+		t.Errorf("Frame() is %v", img.Rect)
+	}
+	if n := len(c.FrameIndexed()); n != ScreenWidth*ScreenHeight {
+		t.Errorf("FrameIndexed() has %d pixels", n)
+	}
+}
+
+func TestSetController(t *testing.T) {
+	c := newTestConsole(t)
+	for _, port := range []int{-1, 2, 4} {
+		if err := c.SetController(port, ButtonA); err == nil {
+			t.Errorf("SetController(%d): no error", port)
+		}
+	}
+	for port, buttons := range []Button{ButtonA | ButtonStart, ButtonB | ButtonRight} {
+		if err := c.SetController(port, buttons); err != nil {
+			t.Fatalf("SetController(%d): %v", port, err)
+		}
+		// Strobe and read the 8 buttons like a game does.
+		c.WriteMemory(0x4016, 1)
+		c.WriteMemory(0x4016, 0)
+		var got Button
+		for i := 0; i < 8; i++ {
+			got |= Button(c.ReadMemory(0x4016+uint16(port))&1) << i
+		}
+		if got != buttons {
+			t.Errorf("port %d reads %08b, want %08b", port, got, buttons)
+		}
+	}
+}
+
+func TestReadWriteMemory(t *testing.T) {
+	c := newTestConsole(t)
+	c.WriteMemory(0x0300, 0x42)
+	if v := c.ReadMemory(0x0300); v != 0x42 {
+		t.Errorf("$0300 = %02X", v)
+	}
+	// WRAM is mirrored every 2 KB.
+	if v := c.ReadMemory(0x0B00); v != 0x42 {
+		t.Errorf("$0B00 = %02X", v)
+	}
+	if v := c.ReadMemory(0xFFFC); v != 0x00 {
+		t.Errorf("reset vector low = %02X", v)
+	}
+}
+
+func TestAudioSamples(t *testing.T) {
+	c := newTestConsole(t)
+	stepFrames(t, c, 1)
+	if n := len(c.AudioSamples()); n < SampleRate/60-1 || n > SampleRate/60+1 {
+		t.Errorf("%d samples in a frame, want about %d", n, SampleRate/60)
+	}
+	if n := len(c.AudioSamples()); n != 0 {
+		t.Errorf("%d samples without a frame", n)
+	}
+
+	// Play pulse 1 at about 440 Hz, volume 15.
+	for _, w := range [][2]uint16{{0x4015, 0x01}, {0x4000, 0xBF}, {0x4002, 0xFD}, {0x4003, 0x00}} {
+		c.WriteMemory(w[0], byte(w[1]))
+	}
+	stepFrames(t, c, 2)
+	samples := c.AudioSamples()
+	lo, hi := slices.Min(samples), slices.Max(samples)
+	if hi-lo < 0.1 || lo < -1 || hi > 1 {
+		t.Errorf("samples range from %f to %f", lo, hi)
+	}
+}
+
+func TestSaveStateRoundTrip(t *testing.T) {
+	c := newTestConsole(t)
+	stepFrames(t, c, 5)
+	state, err := c.SaveState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stepFrames(t, c, 7)
+	want := c.FrameIndexed()
+	wantCount := c.ReadMemory(0x11)
+
+	stepFrames(t, c, 3)
+	if err := c.LoadState(state); err != nil {
+		t.Fatal(err)
+	}
+	stepFrames(t, c, 7)
+	if !slices.Equal(c.FrameIndexed(), want) {
+		t.Error("frame after LoadState differs")
+	}
+	if n := c.ReadMemory(0x11); n != wantCount {
+		t.Errorf("NMI count after LoadState = %d, want %d", n, wantCount)
+	}
+
+	if err := c.LoadState([]byte("not a state")); err == nil {
+		t.Error("LoadState(garbage): no error")
+	}
+	other := testROM()
+	other[0x10+0x20] = 0xEA
+	o, _ := New(other)
+	if err := o.LoadState(state); err == nil {
+		t.Error("LoadState with another ROM: no error")
+	}
+}
+
+// rawState has the fields of the save state of internal/emulator,
+// so that a test can decode, corrupt and encode it again.
+type rawState struct {
+	Version   int
+	ROMCRC    uint32
+	Region    region.Region
+	CPUCycles float64
+	Frames    int
+	LagFrames int
+
+	IsFrameStarted bool
+
+	CPU    cpu.State
+	CPUBus cbus.State
+	PPU    ppu.State
+	PPUBus pbus.State
+	APU    apu.State
+	Cart   cartridge.MapperState
+	Ports  [2]joypad.DeviceState
+}
+
+// uxromROM is testROM on UxROM: its 16K bank is repeated in both PRG banks,
+// so bank 0 at $8000 runs the code and the fixed last bank holds the vectors.
+func uxromROM() []byte {
+	rom := testROM()
+	prg := rom[0x10 : 0x10+0x4000]
+	header := []byte{'N', 'E', 'S', 0x1A, 2, 0, 0x21, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	return slices.Concat(header, prg, prg)
+}
+
+func TestLoadCorruptedState(t *testing.T) {
+	c, err := New(uxromROM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stepFrames(t, c, 5)
+	data, err := c.SaveState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := func(f func(s *rawState)) []byte {
+		var s rawState
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		f(&s)
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&s); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	if err := c.LoadState(corrupt(func(s *rawState) {})); err != nil {
+		t.Fatalf("LoadState(re-encoded): %v", err)
+	}
+
+	stepFrames(t, c, 3)
+	want, err := c.SaveState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range map[string]func(s *rawState){
+		"PRG bank":       func(s *rawState) { s.Cart.Regs[0] = 2 },
+		"negative bank":  func(s *rawState) { s.Cart.Regs[0] = -1 },
+		"front viewport": func(s *rawState) { s.PPU.Front = 2 },
+		"line":           func(s *rawState) { s.PPU.LY = 262 },
+		"dot":            func(s *rawState) { s.PPU.Dot = 341 },
+		"sprite count":   func(s *rawState) { s.PPU.EvalSpriteCount = 65 },
+		"line sprites":   func(s *rawState) { s.PPU.LineSpriteCount = -1 },
+		"sprite eval":    func(s *rawState) { s.PPU.EvalN = 65 },
+		"macro position": func(s *rawState) {
+			pad := s.Ports[1].(joypad.State)
+			pad.Macro, pad.MacroPos = joypad.Macro{0x01}, 1
+			s.Ports[1] = pad
+		},
+	} {
+		if err := c.LoadState(corrupt(f)); err == nil {
+			t.Errorf("LoadState(%s): no error", name)
+		}
+		// The console is left as it was.
+		if got, _ := c.SaveState(); !bytes.Equal(got, want) {
+			t.Errorf("LoadState(%s) changed the state", name)
+		}
+	}
+	stepFrames(t, c, 3)
+}